@@ -1,22 +1,40 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hellobchain/gateway-server/pkg/auth"
 	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/tlsconfig"
 	"github.com/hellobchain/gateway-server/router"
 	"github.com/hellobchain/wswlog/wlogging"
 )
 
 var logger = wlogging.MustGetFileLoggerWithoutName(nil)
 
+const (
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+)
+
 func Init() {
 	loadConfig()
 	cfg := config.Get()
 	initTokenStore(cfg)
-	startWebServer(cfg, registerGinRouter(cfg))
+	r := registerGinRouter(cfg)
+	s := newServers(cfg.Server)
+	startAdminServer(s, cfg)
+	startWebServer(s, cfg, r)
+	waitForShutdown(s, cfg)
 }
 
 func loadConfig() {
@@ -61,10 +79,144 @@ func registerGinRouter(cfg config.Cfg) *gin.Engine {
 	return r
 }
 
-func startWebServer(cfg config.Cfg, r *gin.Engine) {
-	webAddress := config.GetWebServerAddress(cfg)
-	logger.Info("Gateway-server listening on " + webAddress)
-	if err := r.Run(webAddress); err != nil {
-		logger.Fatalf("start failed: %v", err)
+// servers 网关启动的全部监听，退出时统一优雅关闭
+type servers struct {
+	cfg        config.ServerConfig
+	list       []*http.Server
+	certs      *tlsconfig.Server  // HTTPS 证书热加载，未开启时为空
+	baseCtx    context.Context    // 所有请求 context 的父 context
+	cancelBase context.CancelFunc // 等待超时后取消仍在进行的请求
+}
+
+func newServers(cfg config.ServerConfig) *servers {
+	ctx, cancel := context.WithCancel(context.Background())
+	if cfg.ReadHeaderTimeout <= 0 {
+		cfg.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	return &servers{cfg: cfg, baseCtx: ctx, cancelBase: cancel}
+}
+
+// serve 后台启动监听，tlsConf 非空时监听 HTTPS
+func (s *servers) serve(name, addr string, handler http.Handler, tlsConf *tls.Config) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConf,
+		ReadHeaderTimeout: s.cfg.ReadHeaderTimeout,
+		ReadTimeout:       s.cfg.ReadTimeout,
+		WriteTimeout:      s.cfg.WriteTimeout,
+		IdleTimeout:       s.cfg.IdleTimeout,
+		MaxHeaderBytes:    s.cfg.MaxHeaderBytes,
+		BaseContext:       func(net.Listener) context.Context { return s.baseCtx },
+	}
+	s.list = append(s.list, srv)
+	logger.Infof("Gateway-server %s listening on %s", name, addr)
+	go func() {
+		var err error
+		if tlsConf != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("start %s failed: %v", name, err)
+		}
+	}()
+}
+
+// 管理接口独立监听
+func startAdminServer(s *servers, cfg config.Cfg) {
+	if !cfg.Admin.Enabled {
+		return
+	}
+	// 管理接口可强制熔断、调整分流，不允许无鉴权开放
+	if cfg.Admin.Token == "" {
+		logger.Fatalf("admin.token is required when admin is enabled")
+	}
+	r := gin.New()
+	router.RegisterAdmin(r, cfg.Admin)
+	s.serve("admin", config.GetAdminServerAddress(cfg), r, nil)
+}
+
+func startWebServer(s *servers, cfg config.Cfg, r *gin.Engine) {
+	var handler http.Handler = r
+	if cfg.Server.TLS.Enabled {
+		startTLSServer(s, cfg, r)
+		if cfg.Server.TLS.RedirectHTTP {
+			handler = redirectHTTPS(config.GetTLSServerAddress(cfg))
+		}
+	}
+	s.serve("http", config.GetWebServerAddress(cfg), handler, nil)
+}
+
+// HTTPS 监听，证书按 SNI 选择并在文件变化时重新加载
+func startTLSServer(s *servers, cfg config.Cfg, r *gin.Engine) {
+	certs, err := tlsconfig.NewServer(cfg.Server.TLS)
+	if err != nil {
+		logger.Fatalf("server tls: %v", err)
+	}
+	if err := certs.Watch(); err != nil {
+		logger.Errorf("watch tls certificates failed, auto reload disabled: %v", err)
+	}
+	s.certs = certs
+	s.serve("https", config.GetTLSServerAddress(cfg), r, certs.Config())
+}
+
+// 明文请求跳转到 HTTPS 端口，308 保留请求方法与请求体
+func redirectHTTPS(tlsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddress)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, req, "https://"+host+req.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// waitForShutdown 收到 SIGINT/SIGTERM 后停止接收新连接，等待在途请求结束，
+// 超过 shutdown_timeout 时取消剩余请求，最后释放健康检查、上游连接与 redis
+func waitForShutdown(s *servers, cfg config.Cfg) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	timeout := cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	logger.Infof("shutting down, drain timeout %v", timeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range s.list {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(drainCtx); err != nil {
+				logger.Warnf("shutdown %s: %v", srv.Addr, err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+	// websocket 等升级后的连接不受 Shutdown 管理，单独等待
+	if err := router.Drain(drainCtx); err != nil {
+		logger.Warnf("drain timeout, cancel remaining requests")
+	}
+	s.cancelBase()
+	for _, srv := range s.list {
+		_ = srv.Close()
+	}
+
+	router.Close()
+	if s.certs != nil {
+		_ = s.certs.Close()
+	}
+	if err := auth.CloseStore(); err != nil {
+		logger.Errorf("close token store: %v", err)
 	}
+	logger.Info("Gateway-server stopped")
 }
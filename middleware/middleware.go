@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -51,3 +53,22 @@ func CORS() gin.HandlerFunc {
 		AllowHeaders: []string{"*"},
 	})
 }
+
+// Recovery 捕获 panic 返回 500。http.ErrAbortHandler 是转发中途失败时中断连接的信号，
+// 继续向上抛给 http.Server 断开连接，避免客户端把截断的响应当作完整响应
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			logClient.Errorf("panic recovered: %v\n%s", err, debug.Stack())
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
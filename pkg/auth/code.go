@@ -17,3 +17,24 @@ func ResultCode(ctx *gin.Context, code int, msg string) {
 	}
 	ctx.JSON(httpCode, gin.H{"code": code, "msg": msg})
 }
+
+// ClaimsFromContext 取出鉴权中间件保存的 claims
+func ClaimsFromContext(ctx *gin.Context) (JwtMapClaims, bool) {
+	v, ok := ctx.Get(CLAIMS_CTX_KEY)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(JwtMapClaims)
+	return claims, ok
+}
+
+// ResultData 成功响应并携带数据
+func ResultData(ctx *gin.Context, data interface{}) {
+	ctx.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "msg": "success", "data": data})
+}
+
+// ResultStatus 以 code 作为 HTTP 状态码返回，用于客户端需按状态码处理的错误（如 413）
+func ResultStatus(ctx *gin.Context, code int, msg string) {
+	logger.Errorf("[ResultStatus] path:%s code:%d msg:%s", ctx.Request.URL.Path, code, msg)
+	ctx.AbortWithStatusJSON(code, gin.H{"code": code, "msg": msg})
+}
@@ -5,4 +5,5 @@ const (
 	JWT_CLAIMS_KEY  = "jwt:claims:"
 	GLOBAL_QPS_KEY  = "global:qps"
 	IP_QPS_KEY      = "ip:qps:"
+	CLAIMS_CTX_KEY  = "jwt_claims" // gin.Context 中保存 claims 的键
 )
@@ -53,3 +53,8 @@ func (m *memoryStore) Incr(key string) (int64, error) {
 func (m *memoryStore) Expire(key string, expire time.Duration) {
 	m.c.Set(key, 1, expire)
 }
+
+// Close 内存存储无需释放
+func (m *memoryStore) Close() error {
+	return nil
+}
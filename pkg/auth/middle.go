@@ -3,13 +3,29 @@ package auth
 import (
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/routematch"
 )
 
+// newRouteMatcher 与路由表使用相同的匹配规则，保证鉴权按实际转发的路由判断
+func newRouteMatcher(routes []config.RoutesConfig) *routematch.Matcher[config.RoutesConfig] {
+	m := routematch.New[config.RoutesConfig]()
+	for _, r := range routes {
+		m.Add(r, r)
+	}
+	return m
+}
+
 // Middleware 返回一个可插拔的 gin 中间件
 func Middleware() gin.HandlerFunc {
+	var routes atomic.Pointer[routematch.Matcher[config.RoutesConfig]]
+	routes.Store(newRouteMatcher(config.Get().Routes))
+	config.OnChange(func(cfg config.Cfg) {
+		routes.Store(newRouteMatcher(cfg.Routes))
+	})
 	return func(c *gin.Context) {
 
 		cfg := config.Get()
@@ -18,24 +34,23 @@ func Middleware() gin.HandlerFunc {
 			c.Next()
 			return
 		}
-		header := ""
-		var serverFind bool
 		current := c.Request.URL.Path
-		for _, r := range cfg.Routes {
-			if strings.HasPrefix(current, r.Path) {
-				serverFind = true
-				header = r.Header
-				if !r.IsJwt {
-					c.Next()
-					return
-				}
-			}
+		res, ok := routes.Load().Match(routematch.Host(c.Request), c.Request.Method, current)
+		if !ok && len(res.Allow) > 0 {
+			// 方法不允许，由路由表返回 405，不会转发
+			c.Next()
+			return
 		}
-		if !serverFind {
+		if !ok {
 			ResultCode(c, http.StatusNotFound, current+" not found")
 			c.Abort()
 			return
 		}
+		if !res.Value.IsJwt {
+			c.Next()
+			return
+		}
+		header := res.Value.Header
 		for _, p := range jwt.SkipPaths {
 			if matched(p, current) {
 				c.Next()
@@ -56,6 +71,7 @@ func Middleware() gin.HandlerFunc {
 		}
 		// 往请求头写用户数据
 		c.Request.Header.Set("X-User-Info", claims.GetUserName())
+		c.Set(CLAIMS_CTX_KEY, claims)
 		c.Next()
 	}
 }
@@ -69,3 +69,7 @@ func (r *redisStore) Incr(key string) (int64, error) {
 func (r *redisStore) Expire(key string, expire time.Duration) {
 	r.client.Expire(context.Background(), key, expire)
 }
+
+func (r *redisStore) Close() error {
+	return r.client.Close()
+}
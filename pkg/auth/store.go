@@ -11,6 +11,7 @@ type TokenStore interface {
 	GetClaims(key string) (JwtMapClaims, error)
 	Incr(key string) (int64, error)
 	Expire(key string, expire time.Duration)
+	Close() error // 释放连接等资源，进程退出前调用
 }
 
 var store TokenStore
@@ -42,6 +43,14 @@ func Incr(key string) (int64, error) { return store.Incr(key) }
 
 func Expire(key string, expire time.Duration) { store.Expire(key, expire) }
 
+// CloseStore 关闭 TokenStore，未注入时忽略
+func CloseStore() error {
+	if store == nil {
+		return nil
+	}
+	return store.Close()
+}
+
 func validTokenKey(jti string) string { return LOGIN_TOKEN_KEY + jti }
 
 // func claimsKey(jti string) string     { return JWT_CLAIMS_KEY + jti }
@@ -0,0 +1,118 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type State int32
+
+const (
+	StateClosed       State = 0
+	StateOpen         State = 1
+	StateHalfOpen     State = 2
+	StateForcedOpen   State = 3 // 人工强制熔断
+	StateForcedClosed State = 4 // 人工强制放行
+)
+
+var stateNames = map[State]string{
+	StateClosed:       "closed",
+	StateOpen:         "open",
+	StateHalfOpen:     "half-open",
+	StateForcedOpen:   "forced-open",
+	StateForcedClosed: "forced-closed",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int32(s))
+}
+
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// forced 是否为人工强制状态
+func (s State) forced() bool {
+	return s == StateForcedOpen || s == StateForcedClosed
+}
+
+// ParseForceState 解析人工干预状态：open | closed | auto，auto 解析为 StateClosed，只解除人工强制
+func ParseForceState(s string) (State, error) {
+	switch s {
+	case "open":
+		return StateForcedOpen, nil
+	case "closed":
+		return StateForcedClosed, nil
+	case "auto":
+		return StateClosed, nil
+	default:
+		return 0, fmt.Errorf("unknown force state: %s", s)
+	}
+}
+
+var ErrBreakerOpen = errors.New("circuit breaker open")
+
+const (
+	ModeClassic = "classic" // 经典状态机
+	ModeSre     = "sre"     // Google SRE 自适应限流
+)
+
+// Breaker 熔断器
+type Breaker interface {
+	Do(fn func() error) error // 执行 fn，被熔断时返回 ErrBreakerOpen
+	State() State             // 当前状态
+	Counts() Counts           // 当前统计窗口计数
+	Force(st State)           // 人工强制状态，StateClosed 只解除强制，不影响自动判断的状态
+}
+
+type Settings struct {
+	Name                  string           // 路由名称
+	Instance              string           // 后端实例，路由级熔断器为空
+	Observer              Observer         // 状态变化观察者，可为空
+	Enabled               bool             // 熔断器是否开启
+	MaxRequests           uint32           // 半开时最大探测请求数
+	Interval              time.Duration    // 统计窗口
+	Buckets               int              // 统计窗口桶数，默认 10
+	Timeout               time.Duration    // 熔断后多久进入半开；sre 模式下不再拒绝后保持 open 的时长
+	ErrorPercentThreshold float64          // 错误率阈值（0-100）
+	MinRequestAmount      uint32           // 最小请求数才触发错误率计算
+	SlowCallThreshold     time.Duration    // 慢调用耗时阈值，0 表示不统计
+	SlowCallPercent       float64          // 慢调用率阈值（0-100），0 表示不按慢调用熔断
+	Mode                  string           // classic | sre
+	K                     float64          // sre 模式倍率，越小越激进
+	Now                   func() time.Time // 时钟，默认 time.Now，测试可注入
+}
+
+func NewDefaultSettings() Settings {
+	return Settings{
+		Enabled:               true,
+		MaxRequests:           5,
+		Interval:              10 * time.Second,
+		Buckets:               defaultBuckets,
+		Timeout:               5 * time.Second,
+		ErrorPercentThreshold: 50,
+		MinRequestAmount:      10,
+		Mode:                  ModeClassic,
+		K:                     1.5,
+	}
+}
+
+// New 按 Mode 创建熔断器，默认 classic
+func New(settings Settings) Breaker {
+	switch settings.Mode {
+	case ModeSre:
+		return NewSre(settings)
+	default:
+		return NewClassic(settings)
+	}
+}
+
+// isSlow 判断耗时是否超过慢调用阈值
+func isSlow(settings Settings, cost time.Duration) bool {
+	return settings.SlowCallThreshold > 0 && cost >= settings.SlowCallThreshold
+}
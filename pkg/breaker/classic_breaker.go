@@ -0,0 +1,182 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// ClassicBreaker 经典 closed/open/half-open 状态机熔断器
+// 状态只沿 closed→open→half-open→closed（或 half-open→open）流转，人工强制状态除外
+type ClassicBreaker struct {
+	settings Settings
+	mu       sync.Mutex
+	state    State
+	gen      uint64    // 状态代数，跨状态返回的结果不计入
+	stat     *window   // closed 状态下的滚动统计
+	openedAt time.Time // 进入 open 的时间
+	events   []Event   // 待通知的状态变化，释放锁后发送
+
+	// 半开探测计数
+	probes    uint32
+	successes uint32
+}
+
+func NewClassic(settings Settings) *ClassicBreaker {
+	if settings.MaxRequests == 0 {
+		settings.MaxRequests = 1
+	}
+	if settings.Interval <= 0 {
+		settings.Interval = 10 * time.Second
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+	return &ClassicBreaker{
+		settings: settings,
+		stat:     newWindow(settings.Interval, settings.Buckets, settings.Now),
+	}
+}
+
+// 对外唯一入口
+func (b *ClassicBreaker) Do(fn func() error) error {
+	gen, err := b.before()
+	if err != nil {
+		return err
+	}
+	// fn panic（如转发中途超时 http.ErrAbortHandler）时计为失败，释放半开探测名额
+	defer func() {
+		if e := recover(); e != nil {
+			b.after(gen, false, false)
+			panic(e)
+		}
+	}()
+	start := b.settings.Now()
+	err = fn()
+	b.after(gen, err == nil, isSlow(b.settings, b.settings.Now().Sub(start)))
+	return err
+}
+
+// State 当前状态
+func (b *ClassicBreaker) State() State {
+	var st State
+	b.locked(func(now time.Time) {
+		b.refresh(now)
+		st = b.state
+	})
+	return st
+}
+
+// Counts 当前统计窗口计数
+func (b *ClassicBreaker) Counts() Counts {
+	return b.stat.reduce()
+}
+
+// Force 人工强制为 StateForcedOpen / StateForcedClosed，传入 StateClosed 解除强制；
+// 未被强制时解除不改变自动判断的状态，避免自行熔断的实例跳过半开直接恢复
+func (b *ClassicBreaker) Force(st State) {
+	b.locked(func(now time.Time) {
+		if st == StateClosed && !b.state.forced() {
+			return
+		}
+		b.setState(st, now)
+	})
+}
+
+/* ---------- 内部 ---------- */
+func (b *ClassicBreaker) before() (uint64, error) {
+	var (
+		gen uint64
+		err error
+	)
+	b.locked(func(now time.Time) {
+		b.refresh(now)
+		switch b.state {
+		case StateOpen, StateForcedOpen:
+			err = ErrBreakerOpen
+		case StateHalfOpen:
+			if b.probes >= b.settings.MaxRequests {
+				err = ErrBreakerOpen
+				return
+			}
+			b.probes++
+		}
+		gen = b.gen
+	})
+	return gen, err
+}
+
+func (b *ClassicBreaker) after(gen uint64, success, slow bool) {
+	b.locked(func(now time.Time) {
+		b.refresh(now)
+		if gen != b.gen {
+			return
+		}
+		switch b.state {
+		case StateClosed:
+			b.stat.add(success, slow)
+			b.evaluate(now)
+		case StateHalfOpen:
+			// 半开时慢调用同样视为探测失败
+			if !success || (slow && b.settings.SlowCallPercent > 0) {
+				b.setState(StateOpen, now)
+				return
+			}
+			b.successes++
+			if b.successes >= b.settings.MaxRequests {
+				b.setState(StateClosed, now)
+			}
+		}
+	})
+}
+
+// locked 加锁执行 fn，释放锁后通知状态变化
+func (b *ClassicBreaker) locked(fn func(now time.Time)) {
+	b.mu.Lock()
+	fn(b.settings.Now())
+	events := b.events
+	b.events = nil
+	b.mu.Unlock()
+	for _, e := range events {
+		b.settings.notify(e)
+	}
+}
+
+// evaluate closed 状态下按窗口错误率、慢调用率判断是否熔断
+func (b *ClassicBreaker) evaluate(now time.Time) {
+	c := b.stat.reduce()
+	if c.Requests == 0 || c.Requests < uint64(b.settings.MinRequestAmount) {
+		return
+	}
+	failPercent := float64(c.Failures) / float64(c.Requests) * 100
+	slowPercent := float64(c.SlowCalls) / float64(c.Requests) * 100
+	if failPercent >= b.settings.ErrorPercentThreshold ||
+		(b.settings.SlowCallPercent > 0 && slowPercent >= b.settings.SlowCallPercent) {
+		b.setState(StateOpen, now)
+	}
+}
+
+// refresh open 超过 Timeout 后进入半开
+func (b *ClassicBreaker) refresh(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.settings.Timeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *ClassicBreaker) setState(st State, now time.Time) {
+	if b.state == st {
+		return
+	}
+	counts := b.stat.reduce()
+	from := b.state
+	b.state = st
+	b.gen++
+	b.probes = 0
+	b.successes = 0
+	switch st {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.stat.reset()
+	}
+	b.events = append(b.events, b.settings.event(from, st, counts, now))
+}
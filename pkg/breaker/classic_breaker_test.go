@@ -0,0 +1,232 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBackend = errors.New("backend 5xx")
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func (f *fakeClock) Add(d time.Duration) { f.now = f.now.Add(d) }
+
+func newTestBreaker(clock *fakeClock) *ClassicBreaker {
+	return NewClassic(Settings{
+		Enabled:               true,
+		MaxRequests:           2,
+		Interval:              10 * time.Second,
+		Buckets:               10,
+		Timeout:               5 * time.Second,
+		ErrorPercentThreshold: 50,
+		MinRequestAmount:      4,
+		Now:                   clock.Now,
+	})
+}
+
+func call(b Breaker, fail bool) error {
+	return b.Do(func() error {
+		if fail {
+			return errBackend
+		}
+		return nil
+	})
+}
+
+func TestClassicOpenHalfOpenClosed(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+
+	for i := 0; i < 4; i++ {
+		_ = call(b, true)
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+	if err := call(b, false); err != ErrBreakerOpen {
+		t.Fatalf("open breaker err = %v, want ErrBreakerOpen", err)
+	}
+
+	// 超过统计窗口但未到 Timeout 仍保持 open
+	clock.Add(4 * time.Second)
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open before timeout", st)
+	}
+
+	clock.Add(time.Second)
+	if st := b.State(); st != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open", st)
+	}
+	if err := call(b, false); err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if st := b.State(); st != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open after one probe", st)
+	}
+	if err := call(b, false); err != nil {
+		t.Fatalf("probe err = %v", err)
+	}
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed", st)
+	}
+}
+
+func TestClassicHalfOpenFailureReopens(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+	for i := 0; i < 4; i++ {
+		_ = call(b, true)
+	}
+	clock.Add(5 * time.Second)
+	if err := call(b, true); err != errBackend {
+		t.Fatalf("probe err = %v, want backend error", err)
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+	// 重新计时
+	clock.Add(4 * time.Second)
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+}
+
+func TestClassicHalfOpenLimitsProbes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+	for i := 0; i < 4; i++ {
+		_ = call(b, true)
+	}
+	clock.Add(5 * time.Second)
+
+	// 探测请求未返回前，超过 MaxRequests 的请求被拒绝
+	release := make(chan struct{})
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		started := make(chan struct{})
+		go func() {
+			done <- b.Do(func() error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+	}
+	if err := call(b, false); err != ErrBreakerOpen {
+		t.Fatalf("extra probe err = %v, want ErrBreakerOpen", err)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("probe err = %v", err)
+		}
+	}
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed", st)
+	}
+}
+
+func TestClassicWindowSlides(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+
+	// 两次失败落在最早的桶
+	_ = call(b, true)
+	_ = call(b, true)
+	clock.Add(9 * time.Second)
+	// 窗口内 3 失败 1 成功，错误率超过阈值触发熔断
+	_ = call(b, false)
+	_ = call(b, true)
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+
+	b = newTestBreaker(clock)
+	_ = call(b, true)
+	_ = call(b, true)
+	// 最早的桶滑出窗口后旧失败不再计入
+	clock.Add(11 * time.Second)
+	_ = call(b, false)
+	_ = call(b, false)
+	_ = call(b, false)
+	_ = call(b, true)
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed", st)
+	}
+}
+
+func TestWindowReduce(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWindow(time.Second, 4, clock.Now)
+	w.add(true, true)
+	w.add(false, false)
+	clock.Add(500 * time.Millisecond)
+	w.add(false, false)
+	if c := w.reduce(); c != (Counts{Requests: 3, Failures: 2, SlowCalls: 1}) {
+		t.Fatalf("reduce = %+v, want 3/2/1", c)
+	}
+	clock.Add(600 * time.Millisecond)
+	if c := w.reduce(); c != (Counts{Requests: 1, Failures: 1}) {
+		t.Fatalf("reduce = %+v, want 1/1/0", c)
+	}
+	clock.Add(time.Hour)
+	if c := w.reduce(); c != (Counts{}) {
+		t.Fatalf("reduce = %+v, want empty", c)
+	}
+}
+
+func TestClassicSlowCallsOpen(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := NewClassic(Settings{
+		Enabled:               true,
+		MaxRequests:           1,
+		Interval:              10 * time.Second,
+		Timeout:               5 * time.Second,
+		ErrorPercentThreshold: 50,
+		MinRequestAmount:      4,
+		SlowCallThreshold:     time.Second,
+		SlowCallPercent:       50,
+		Now:                   clock.Now,
+	})
+	for i := 0; i < 4; i++ {
+		_ = b.Do(func() error {
+			if i%2 == 0 {
+				clock.Add(2 * time.Second)
+			}
+			return nil
+		})
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open on slow call rate", st)
+	}
+}
+
+func TestClassicForceAutoOnlyClearsForcedState(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestBreaker(clock)
+	for i := 0; i < 4; i++ {
+		_ = call(b, true)
+	}
+	// 自行熔断时 auto 不生效，仍需经过半开探测
+	b.Force(StateClosed)
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open after auto", st)
+	}
+	clock.Add(5 * time.Second)
+	if st := b.State(); st != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open", st)
+	}
+
+	b.Force(StateForcedOpen)
+	b.Force(StateClosed)
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed after releasing forced open", st)
+	}
+}
@@ -0,0 +1,109 @@
+package breaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Group 单条路由的熔断器集合：默认整条路由共用一个熔断器，
+// 开启 perInstance 后按后端实例懒创建独立熔断器
+type Group struct {
+	name        string   // 路由名称
+	settings    Settings // 熔断配置
+	perInstance bool     // 是否按实例熔断
+	route       Breaker  // 路由级熔断器
+	instances   sync.Map // addr -> Breaker
+
+	mu     sync.Mutex
+	forced State // 按实例熔断时对后续新建实例生效的人工状态
+}
+
+func NewGroup(name string, settings Settings, perInstance bool) *Group {
+	settings.Name = name
+	g := &Group{name: name, settings: settings, perInstance: perInstance}
+	if !perInstance {
+		g.route = New(settings)
+	}
+	return g
+}
+
+func (g *Group) Name() string {
+	return g.name
+}
+
+func (g *Group) Enabled() bool {
+	return g.settings.Enabled
+}
+
+// PerInstance 是否按实例熔断
+func (g *Group) PerInstance() bool {
+	return g.perInstance
+}
+
+// Get 返回实例对应的熔断器
+func (g *Group) Get(instance string) Breaker {
+	if !g.perInstance {
+		return g.route
+	}
+	if b, ok := g.instances.Load(instance); ok {
+		return b.(Breaker)
+	}
+	settings := g.settings
+	settings.Instance = instance
+	nb := New(settings)
+	b, loaded := g.instances.LoadOrStore(instance, nb)
+	if !loaded {
+		g.mu.Lock()
+		if g.forced != StateClosed {
+			nb.Force(g.forced)
+		}
+		g.mu.Unlock()
+	}
+	return b.(Breaker)
+}
+
+// Status 熔断器运行状态
+type Status struct {
+	Route    string `json:"route"`
+	Instance string `json:"instance,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	State    State  `json:"state"`
+	Counts   Counts `json:"counts"`
+}
+
+// Status 返回路由下所有熔断器状态
+func (g *Group) Status() []Status {
+	if !g.perInstance {
+		return []Status{g.status("", g.route)}
+	}
+	var list []Status
+	g.instances.Range(func(k, v any) bool {
+		list = append(list, g.status(k.(string), v.(Breaker)))
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Instance < list[j].Instance })
+	return list
+}
+
+func (g *Group) status(instance string, b Breaker) Status {
+	return Status{Route: g.name, Instance: instance, Enabled: g.Enabled(), State: b.State(), Counts: b.Counts()}
+}
+
+// Force 人工干预状态，instance 为空时作用于路由下全部熔断器
+func (g *Group) Force(instance string, st State) {
+	if !g.perInstance {
+		g.route.Force(st)
+		return
+	}
+	if instance != "" {
+		g.Get(instance).Force(st)
+		return
+	}
+	g.mu.Lock()
+	g.forced = st
+	g.mu.Unlock()
+	g.instances.Range(func(_, v any) bool {
+		v.(Breaker).Force(st)
+		return true
+	})
+}
@@ -0,0 +1,43 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupPerInstance(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	settings := Settings{
+		Enabled:               true,
+		MaxRequests:           2,
+		Interval:              10 * time.Second,
+		Buckets:               10,
+		Timeout:               5 * time.Second,
+		ErrorPercentThreshold: 50,
+		MinRequestAmount:      4,
+		Now:                   clock.Now,
+	}
+	g := NewGroup("dm", settings, true)
+	for i := 0; i < 4; i++ {
+		_ = call(g.Get("a:80"), true)
+	}
+	if st := g.Get("a:80").State(); st != StateOpen {
+		t.Fatalf("a state = %v, want open", st)
+	}
+	// 按实例熔断时 a 熔断不影响 b
+	if st := g.Get("b:80").State(); st != StateClosed {
+		t.Fatalf("b state = %v, want closed", st)
+	}
+	if err := call(g.Get("b:80"), false); err != nil {
+		t.Fatalf("b err = %v, want pass through", err)
+	}
+
+	// 未按实例熔断时所有实例共用路由级熔断器
+	g = NewGroup("dm", settings, false)
+	for i := 0; i < 4; i++ {
+		_ = call(g.Get("a:80"), true)
+	}
+	if err := call(g.Get("b:80"), false); err != ErrBreakerOpen {
+		t.Fatalf("b err = %v, want ErrBreakerOpen from the shared breaker", err)
+	}
+}
@@ -0,0 +1,60 @@
+package breaker
+
+import (
+	"time"
+
+	"github.com/hellobchain/wswlog/wlogging"
+)
+
+var logger = wlogging.MustGetFileLoggerWithoutName(nil)
+
+// Event 熔断器状态变化事件
+type Event struct {
+	Route    string    `json:"route"`              // 路由名称
+	Instance string    `json:"instance,omitempty"` // 后端实例，路由级熔断器为空
+	From     State     `json:"from"`               // 原状态
+	To       State     `json:"to"`                 // 新状态
+	Counts   Counts    `json:"counts"`             // 变化时的窗口计数
+	Time     time.Time `json:"time"`               // 变化时间
+}
+
+// Observer 熔断器状态变化观察者，回调在熔断器锁外同步执行，不应阻塞
+type Observer interface {
+	OnStateChange(e Event)
+}
+
+// ObserverFunc 函数适配 Observer
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) OnStateChange(e Event) { f(e) }
+
+// Observers 组合多个观察者
+type Observers []Observer
+
+func (os Observers) OnStateChange(e Event) {
+	for _, o := range os {
+		o.OnStateChange(e)
+	}
+}
+
+// LogObserver 将状态变化写入日志
+type LogObserver struct{}
+
+func (LogObserver) OnStateChange(e Event) {
+	name := e.Route
+	if e.Instance != "" {
+		name += " " + e.Instance
+	}
+	logger.Warnf("[breaker] %s: %s -> %s, requests=%d failures=%d slow=%d",
+		name, e.From, e.To, e.Counts.Requests, e.Counts.Failures, e.Counts.SlowCalls)
+}
+
+func (s Settings) event(from, to State, counts Counts, now time.Time) Event {
+	return Event{Route: s.Name, Instance: s.Instance, From: from, To: to, Counts: counts, Time: now}
+}
+
+func (s Settings) notify(e Event) {
+	if s.Observer != nil {
+		s.Observer.OnStateChange(e)
+	}
+}
@@ -1,135 +1,124 @@
 package breaker
 
 import (
-	"errors"
-	"sync/atomic"
+	"math"
+	"math/rand/v2"
+	"sync"
 	"time"
 )
 
-type State int32
-
-const (
-	StateClosed   State = 0
-	StateOpen     State = 1
-	StateHalfOpen State = 2
-)
-
-var ErrBreakerOpen = errors.New("circuit breaker open")
-
-type Settings struct {
-	Enabled               bool          // 熔断器是否开启
-	MaxRequests           uint32        // 半开时最大探测请求数
-	Interval              time.Duration // 统计窗口
-	Timeout               time.Duration // 熔断后多久进入半开
-	ErrorPercentThreshold float64       // 错误率阈值（0-100）
-	MinRequestAmount      uint32        // 最小请求数才触发错误率计算
-}
-
-func NewDefaultSettings() Settings {
-	return Settings{
-		Enabled:               true,
-		MaxRequests:           5,
-		Interval:              10 * time.Second,
-		Timeout:               5 * time.Second,
-		ErrorPercentThreshold: 50,
-		MinRequestAmount:      10,
-	}
-}
-
+// SreBreaker Google SRE 客户端自适应限流
+// 拒绝概率 p = max(0, (requests - K*accepts) / (requests + 1))，
+// 后端越不健康拒绝越多，恢复后逐步放量，不会在全开全关之间抖动。
+// 对外状态：p > 0 时为 open（按概率拒绝）；p 回落到 0 并持续 Timeout 后才恢复 closed，
+// 避免在阈值附近逐个请求来回切换并刷屏告警
 type SreBreaker struct {
 	settings Settings
-	state    int32 // atomic
-
-	// 滑动窗口计数器
-	lastResetTime int64 // unix nano
-	reqAll        uint32
-	reqFail       uint32
-}
+	stat     *window // requests / accepts 滚动统计
 
-func New(settings Settings) *SreBreaker {
-	b := &SreBreaker{settings: settings}
-	return b
+	mu         sync.Mutex
+	state      State
+	lastReject time.Time // 最近一次 p > 0 的时间
 }
 
-func (b *SreBreaker) Enabled() bool {
-	return b.settings.Enabled
+func NewSre(settings Settings) *SreBreaker {
+	if settings.K <= 0 {
+		settings.K = 1.5
+	}
+	if settings.Interval <= 0 {
+		settings.Interval = 10 * time.Second
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 5 * time.Second
+	}
+	return &SreBreaker{
+		settings: settings,
+		stat:     newWindow(settings.Interval, settings.Buckets, settings.Now),
+	}
 }
 
-// 对外唯一入口
 func (b *SreBreaker) Do(fn func() error) error {
-	for {
-		st := State(atomic.LoadInt32(&b.state))
-		switch st {
-		case StateClosed:
-			return b.call(fn)
-		case StateOpen:
-			return ErrBreakerOpen
-		case StateHalfOpen:
-			if atomic.LoadUint32(&b.reqAll) >= b.settings.MaxRequests {
-				return ErrBreakerOpen
-			}
-			err := b.call(fn)
-			if err == nil {
-				b.onSuccess()
-			} else {
-				b.onFail()
-			}
-			return err
-		}
+	switch b.State() {
+	case StateForcedOpen:
+		return ErrBreakerOpen
+	case StateForcedClosed:
+		return fn()
 	}
-}
-
-/* ---------- 内部 ---------- */
-func (b *SreBreaker) call(fn func() error) error {
-	b.onRequest()
-	err := fn()
-	if err != nil {
-		b.onFail()
-		return err
+	if !b.accept() {
+		// 被拒绝的请求同样计入 requests
+		b.stat.add(false, false)
+		return ErrBreakerOpen
 	}
-	b.onSuccess()
-	return nil
+	// fn panic（如转发中途超时 http.ErrAbortHandler）时计为失败
+	defer func() {
+		if e := recover(); e != nil {
+			b.stat.add(false, false)
+			panic(e)
+		}
+	}()
+	start := b.settings.Now()
+	err := fn()
+	slow := isSlow(b.settings, b.settings.Now().Sub(start))
+	// 开启慢调用熔断时，慢调用不计入 accepts
+	b.stat.add(err == nil && !(slow && b.settings.SlowCallPercent > 0), slow)
+	return err
 }
 
-func (b *SreBreaker) onRequest() {
-	b.lazyReset()
-	atomic.AddUint32(&b.reqAll, 1)
+func (b *SreBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
 }
 
-func (b *SreBreaker) onFail() {
-	atomic.AddUint32(&b.reqFail, 1)
-	b.evaluate()
+func (b *SreBreaker) Counts() Counts {
+	return b.stat.reduce()
 }
 
-func (b *SreBreaker) onSuccess() {
-	b.evaluate()
+// Force 人工强制为 StateForcedOpen / StateForcedClosed，传入 StateClosed 恢复自适应；
+// 未被强制时不改变当前状态
+func (b *SreBreaker) Force(st State) {
+	b.setState(st, func(cur State) bool { return st != StateClosed || cur.forced() })
 }
 
-func (b *SreBreaker) evaluate() {
-	all := atomic.LoadUint32(&b.reqAll)
-	fail := atomic.LoadUint32(&b.reqFail)
-	if all < b.settings.MinRequestAmount {
-		return
+// accept 按拒绝概率决定是否放行
+func (b *SreBreaker) accept() bool {
+	p := b.rejectProbability(b.stat.reduce())
+	now := b.settings.Now()
+	// 人工强制期间不自动切换
+	if p > 0 {
+		b.mu.Lock()
+		b.lastReject = now
+		b.mu.Unlock()
+		b.setState(StateOpen, func(cur State) bool { return cur == StateClosed })
+		return rand.Float64() >= p
 	}
-	percent := float64(fail) / float64(all) * 100
-	if percent >= b.settings.ErrorPercentThreshold {
-		atomic.StoreInt32(&b.state, int32(StateOpen))
-		// 启动倒计时：Timeout 后进入半开
-		time.AfterFunc(b.settings.Timeout, func() {
-			atomic.StoreInt32(&b.state, int32(StateHalfOpen))
-			atomic.StoreUint32(&b.reqAll, 0)
-			atomic.StoreUint32(&b.reqFail, 0)
-		})
+	b.setState(StateClosed, func(cur State) bool {
+		return cur == StateOpen && now.Sub(b.lastReject) >= b.settings.Timeout
+	})
+	return true
+}
+
+// rejectProbability 窗口请求数未达到 MinRequestAmount 时不拒绝
+func (b *SreBreaker) rejectProbability(c Counts) float64 {
+	if c.Requests < uint64(b.settings.MinRequestAmount) {
+		return 0
 	}
+	accepts := float64(c.Requests - c.Failures)
+	return math.Max(0, (float64(c.Requests)-b.settings.K*accepts)/float64(c.Requests+1))
 }
 
-// 滑动窗口重置
-func (b *SreBreaker) lazyReset() {
-	now := time.Now().UnixNano()
-	if now-atomic.LoadInt64(&b.lastResetTime) > int64(b.settings.Interval) {
-		atomic.StoreInt64(&b.lastResetTime, now)
-		atomic.StoreUint32(&b.reqAll, 0)
-		atomic.StoreUint32(&b.reqFail, 0)
-		atomic.StoreInt32(&b.state, int32(StateClosed))
+// setState 在 cond 满足时切换状态并通知观察者
+func (b *SreBreaker) setState(st State, cond func(cur State) bool) {
+	b.mu.Lock()
+	from := b.state
+	if from == st || !cond(from) {
+		b.mu.Unlock()
+		return
 	}
+	b.state = st
+	b.mu.Unlock()
+	b.settings.notify(b.settings.event(from, st, b.stat.reduce(), b.settings.Now()))
 }
@@ -0,0 +1,170 @@
+package breaker
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestSre(clock *fakeClock, observer Observer) *SreBreaker {
+	return NewSre(Settings{
+		Enabled:          true,
+		Interval:         10 * time.Second,
+		Buckets:          10,
+		Timeout:          5 * time.Second,
+		MinRequestAmount: 10,
+		K:                1.5,
+		Observer:         observer,
+		Now:              clock.Now,
+	})
+}
+
+// eventRecorder 记录状态变化
+type eventRecorder struct {
+	events []Event
+}
+
+func (r *eventRecorder) OnStateChange(e Event) { r.events = append(r.events, e) }
+
+func TestSreRejectProbability(t *testing.T) {
+	cases := []struct {
+		name     string
+		k        float64
+		requests uint64
+		failures uint64
+		want     float64
+	}{
+		{"below min request amount", 1.5, 9, 9, 0},
+		{"all success", 1.5, 100, 0, 0},
+		{"within k tolerance", 1.5, 100, 30, 0},
+		{"all failure", 1.5, 10, 10, 10.0 / 11},
+		{"partial failure", 1.5, 10, 6, 4.0 / 11},
+		{"larger k tolerates more", 2, 10, 6, 2.0 / 11},
+		{"k of 1 rejects any failure", 1, 10, 1, 1.0 / 11},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewSre(Settings{K: tc.k, MinRequestAmount: 10})
+			got := b.rejectProbability(Counts{Requests: tc.requests, Failures: tc.failures})
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("p = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSreRejectsAndCountsRejections(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestSre(clock, nil)
+
+	// 未达到最小请求数前失败也全部放行
+	for i := 0; i < 9; i++ {
+		if err := call(b, true); err != errBackend {
+			t.Fatalf("call %d err = %v, want backend error", i, err)
+		}
+	}
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed below min request amount", st)
+	}
+
+	rejected := 0
+	for i := 0; i < 200; i++ {
+		if err := call(b, true); err == ErrBreakerOpen {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Fatal("no request rejected on failing backend")
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+	// 被拒绝的请求同样计入 requests
+	if c := b.Counts(); c.Requests != 209 || c.Failures != 209 {
+		t.Fatalf("counts = %+v, want 209 requests and failures", c)
+	}
+}
+
+func TestSreRecoversAsWindowSlides(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := &eventRecorder{}
+	b := newTestSre(clock, rec)
+	for i := 0; i < 20; i++ {
+		_ = call(b, true)
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+
+	// 窗口末尾仍在拒绝
+	clock.Add(9500 * time.Millisecond)
+	_ = call(b, true)
+
+	// 早期失败滑出窗口后不再拒绝，但距最近一次拒绝未满 Timeout 仍保持 open
+	clock.Add(1500 * time.Millisecond)
+	if err := call(b, false); err != nil {
+		t.Fatalf("err = %v, want accepted after window slides", err)
+	}
+	if c := b.Counts(); c.Requests != 2 {
+		t.Fatalf("counts = %+v, want 2 requests left in window", c)
+	}
+	if st := b.State(); st != StateOpen {
+		t.Fatalf("state = %v, want open within timeout", st)
+	}
+	clock.Add(4 * time.Second)
+	_ = call(b, false)
+	if st := b.State(); st != StateClosed {
+		t.Fatalf("state = %v, want closed", st)
+	}
+	if len(rec.events) != 2 || rec.events[0].To != StateOpen || rec.events[1].To != StateClosed {
+		t.Fatalf("events = %+v, want open then closed", rec.events)
+	}
+}
+
+func TestSreStateDoesNotFlap(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := &eventRecorder{}
+	b := newTestSre(clock, rec)
+	// 失败率在 K 容忍度附近波动，p 在 0 与正数之间反复
+	for i := 0; i < 1000; i++ {
+		_ = call(b, i%3 == 0)
+		clock.Add(10 * time.Millisecond)
+	}
+	if len(rec.events) > 1 {
+		t.Fatalf("got %d state changes, want at most one while hovering at the threshold", len(rec.events))
+	}
+}
+
+func TestSreForce(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newTestSre(clock, nil)
+	b.Force(StateForcedOpen)
+	if err := call(b, false); err != ErrBreakerOpen {
+		t.Fatalf("err = %v, want ErrBreakerOpen when forced open", err)
+	}
+	b.Force(StateForcedClosed)
+	for i := 0; i < 50; i++ {
+		if err := call(b, true); err != errBackend {
+			t.Fatalf("err = %v, want pass through when forced closed", err)
+		}
+	}
+	if st := b.State(); st != StateForcedClosed {
+		t.Fatalf("state = %v, want forced closed", st)
+	}
+}
+
+func TestSrePanicCountsAsFailure(t *testing.T) {
+	b := newTestSre(&fakeClock{now: time.Unix(0, 0)}, nil)
+	func() {
+		defer func() {
+			if e := recover(); e != http.ErrAbortHandler {
+				t.Fatalf("recovered %v, want http.ErrAbortHandler re-panicked", e)
+			}
+		}()
+		_ = b.Do(func() error { panic(http.ErrAbortHandler) })
+	}()
+	if c := b.Counts(); c.Requests != 1 || c.Failures != 1 {
+		t.Fatalf("counts = %+v, want one failed request", c)
+	}
+}
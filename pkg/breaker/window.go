@@ -0,0 +1,99 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultBuckets = 10
+
+// Counts 窗口内计数
+type Counts struct {
+	Requests  uint64 `json:"requests"`   // 请求总数
+	Failures  uint64 `json:"failures"`   // 失败数
+	SlowCalls uint64 `json:"slow_calls"` // 慢调用数
+}
+
+// bucket 单个时间桶计数
+type bucket = Counts
+
+// window 按时间桶滚动的统计窗口
+type window struct {
+	mu       sync.Mutex
+	buckets  []bucket      // 环形桶
+	span     time.Duration // 单桶时长
+	offset   int           // 当前桶下标
+	lastTime time.Time     // 当前桶起始时间
+	now      func() time.Time
+}
+
+func newWindow(interval time.Duration, size int, now func() time.Time) *window {
+	if size <= 0 {
+		size = defaultBuckets
+	}
+	span := interval / time.Duration(size)
+	if span <= 0 {
+		span = time.Millisecond
+	}
+	return &window{
+		buckets:  make([]bucket, size),
+		span:     span,
+		lastTime: now(),
+		now:      now,
+	}
+}
+
+// add 记录一次请求结果
+func (w *window) add(success, slow bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(w.now())
+	b := &w.buckets[w.offset]
+	b.Requests++
+	if !success {
+		b.Failures++
+	}
+	if slow {
+		b.SlowCalls++
+	}
+}
+
+// reduce 汇总窗口内计数
+func (w *window) reduce() (c Counts) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(w.now())
+	for _, b := range w.buckets {
+		c.Requests += b.Requests
+		c.Failures += b.Failures
+		c.SlowCalls += b.SlowCalls
+	}
+	return
+}
+
+// reset 清空所有桶
+func (w *window) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.offset = 0
+	w.lastTime = w.now()
+}
+
+// advance 滑动到 now 所在桶，清空经过的过期桶
+func (w *window) advance(now time.Time) {
+	n := int(now.Sub(w.lastTime) / w.span)
+	if n <= 0 {
+		return
+	}
+	if n > len(w.buckets) {
+		n = len(w.buckets)
+	}
+	for i := 0; i < n; i++ {
+		w.offset = (w.offset + 1) % len(w.buckets)
+		w.buckets[w.offset] = bucket{}
+	}
+	w.lastTime = w.lastTime.Add(now.Sub(w.lastTime) / w.span * w.span)
+}
@@ -20,18 +20,217 @@ type Cfg struct {
 	JWT             JWT             `mapstructure:"jwt"`       // JWT 配置
 	InterceptConfig InterceptConfig `mapstructure:"intercept"` // 拦截配置
 	Breaker         Breaker         `mapstructure:"breaker"`   // 熔断配置
+	Admin           AdminConfig     `mapstructure:"admin"`     // 管理接口配置
 }
 type ServerConfig struct {
 	Port     int    `mapstructure:"port"`      // 监听端口
 	LogLevel string `mapstructure:"log_level"` // 日志级别 info debug error
 	Mode     string `mapstructure:"mode"`      // 运行模式 debug release test
+
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`    // 优雅退出等待在途请求的最长时间，默认 30s
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // 读取请求头超时，默认 10s
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`        // 读取整个请求（含请求体）超时，为空不限制
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`       // 写响应超时，为空不限制，开启后会中断长连接流式响应
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`        // keep-alive 空闲超时，为空时取 read_timeout
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`    // 请求头最大字节数，默认 1MB
+
+	TLS ServerTLSConfig `mapstructure:"tls"` // HTTPS 监听
+}
+
+// ServerTLSConfig 网关 HTTPS 监听，证书文件变化时自动重新加载
+type ServerTLSConfig struct {
+	Enabled      bool         `mapstructure:"enabled"`        // 是否开启
+	Port         int          `mapstructure:"port"`           // HTTPS 端口，默认 8443
+	Certs        []CertConfig `mapstructure:"certs"`          // 证书列表，按 SNI 选择，无匹配时使用第一张
+	ClientCAFile string       `mapstructure:"client_ca_file"` // 校验客户端证书的 CA
+	ClientAuth   string       `mapstructure:"client_auth"`    // none | request | require | verify_if_given | require_and_verify，配置 CA 时默认 require_and_verify
+	MinVersion   string       `mapstructure:"min_version"`    // 最低 TLS 版本，默认 1.2
+	RedirectHTTP bool         `mapstructure:"redirect_http"`  // 明文端口只做 HTTPS 跳转
+}
+
+// CertConfig 证书与私钥，均为 PEM 文件
+type CertConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
 }
 type RoutesConfig struct {
+	Name                string                `mapstructure:"name"`                  // 路由名称，需唯一，默认由 methods、hosts 与 path 组成
 	Path                string                `mapstructure:"path"`                  // 匹配的路径
+	Hosts               []string              `mapstructure:"hosts"`                 // 匹配的 Host，支持 *.example.com，为空匹配任意 Host
+	Methods             []string              `mapstructure:"methods"`               // 允许的请求方法，为空允许全部，路径命中但方法不符时返回 405
+	Priority            int                   `mapstructure:"priority"`              // 匹配优先级，多条路由命中时先比较优先级，再比较 Host 与最长路径
 	Targets             []RouterTargetsConfig `mapstructure:"targets"`               // 目标地址
+	Match               []MatchRuleConfig     `mapstructure:"match"`                 // 灰度规则，按顺序匹配，都不命中时转发到 targets
+	Groups              []TargetGroupConfig   `mapstructure:"groups"`                // 命名目标组，按比例分流，配置后替代 targets
+	Sticky              HashKeyConfig         `mapstructure:"sticky"`                // 目标组分流的会话保持键，为空时按请求随机分流
+	LbStrategy          string                `mapstructure:"lb_strategy"`           // 负载均衡策略 round_robin | least_conn | p2c_ewma | consistent_hash
+	HashKey             HashKeyConfig         `mapstructure:"hash_key"`              // 一致性哈希键来源
 	IsJwt               bool                  `mapstructure:"is_jwt"`                // 是否需要 JWT
 	Header              string                `mapstructure:"header"`                // 请求头
 	HealthCheckInterval int                   `mapstructure:"health_check_interval"` // 健康检查间隔
+	HealthCheck         HealthCheckConfig     `mapstructure:"health_check"`          // 主动健康检查
+	Outlier             OutlierConfig         `mapstructure:"outlier"`               // 被动健康检查
+	Retry               RetryConfig           `mapstructure:"retry"`                 // 失败重试
+	Transport           TransportConfig       `mapstructure:"transport"`             // 上游连接参数
+	MaxBodyBytes        int64                 `mapstructure:"max_body_bytes"`        // 请求体上限，超出返回 413，为空不限制
+	Timeout             TimeoutConfig         `mapstructure:"timeout"`               // 转发超时
+	Rewrite             RewriteConfig         `mapstructure:"rewrite"`               // 转发前改写路径与查询参数
+	TLS                 UpstreamTLSConfig     `mapstructure:"tls"`                   // https 上游的 TLS 客户端配置
+	Breaker             RouteBreaker          `mapstructure:"breaker"`               // 路由级熔断配置
+	Failure             FailureConfig         `mapstructure:"failure"`               // 熔断失败判定规则
+	Fallback            FallbackConfig        `mapstructure:"fallback"`              // 降级配置
+}
+
+// GetName 路由名称，未配置时由方法、Host 与 path 组成，如 GET,POST api.example.com/dm；
+// 只配置 path 时即为 path
+func (r RoutesConfig) GetName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	name := strings.Join(r.Hosts, ",") + r.Path
+	if len(r.Methods) > 0 {
+		name = strings.Join(r.Methods, ",") + " " + name
+	}
+	return name
+}
+
+// HealthCheckConfig 主动健康检查，http 类型按目标 protocol 发起 HTTP(S) 请求
+type HealthCheckConfig struct {
+	Type               string            `mapstructure:"type"`                // tcp | http，默认 tcp
+	Interval           time.Duration     `mapstructure:"interval"`            // 检测间隔，为空时取 health_check_interval，默认 5s
+	Timeout            time.Duration     `mapstructure:"timeout"`             // 单次探测超时，默认 3s
+	Jitter             time.Duration     `mapstructure:"jitter"`              // 间隔随机抖动上限
+	HealthyThreshold   int               `mapstructure:"healthy_threshold"`   // 连续成功多少次恢复，默认 1
+	UnhealthyThreshold int               `mapstructure:"unhealthy_threshold"` // 连续失败多少次摘除，默认 1
+	Method             string            `mapstructure:"method"`              // http 请求方法，默认 GET
+	Path               string            `mapstructure:"path"`                // http 探测路径，默认 /
+	Headers            map[string]string `mapstructure:"headers"`             // http 请求头
+	ExpectedStatus     string            `mapstructure:"expected_status"`     // 期望状态码，如 200 或 200-399，默认 200-399
+	BodyContains       string            `mapstructure:"body_contains"`       // 响应体需包含的字符串
+	BodyRegex          string            `mapstructure:"body_regex"`          // 响应体需匹配的正则
+}
+
+// OutlierConfig 被动健康检查：根据真实流量驱逐连续失败的实例
+type OutlierConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`              // 是否开启
+	ConsecutiveErrors  int           `mapstructure:"consecutive_errors"`   // 连续失败多少次驱逐，默认 5
+	BaseEjectionTime   time.Duration `mapstructure:"base_ejection_time"`   // 首次驱逐时长，之后每次翻倍，默认 30s
+	MaxEjectionTime    time.Duration `mapstructure:"max_ejection_time"`    // 单次驱逐时长上限，默认 300s
+	MaxEjectionPercent int           `mapstructure:"max_ejection_percent"` // 同时被驱逐实例的最大比例，默认 50
+}
+
+// RetryConfig 失败重试，重试时优先选择未尝试过的实例
+type RetryConfig struct {
+	Attempts        int           `mapstructure:"attempts"`          // 最大尝试次数（含首次），小于 2 不重试
+	RetryOn         []string      `mapstructure:"retry_on"`          // connect-failure | timeout | 5xx | 具体状态码，默认 connect-failure、502、503
+	NonIdempotent   bool          `mapstructure:"non_idempotent"`    // 非幂等方法（POST、PATCH 等）是否重试，默认否
+	Backoff         time.Duration `mapstructure:"backoff"`           // 首次重试退避，之后翻倍并加随机抖动，默认 25ms
+	MaxBackoff      time.Duration `mapstructure:"max_backoff"`       // 退避上限，默认 250ms
+	PerTryTimeout   time.Duration `mapstructure:"per_try_timeout"`   // 单次尝试超时，timeout.per_try 优先
+	BufferBodyBytes int64         `mapstructure:"buffer_body_bytes"` // 可重放的请求体上限，超出时不重试，默认 64KB
+}
+
+// TimeoutConfig 转发超时，超时返回 504 并计为熔断失败
+type TimeoutConfig struct {
+	Total  time.Duration `mapstructure:"total"`   // 整个请求（含重试）的超时，为空不限制
+	PerTry time.Duration `mapstructure:"per_try"` // 单次尝试超时，为空时取 retry.per_try_timeout
+	Header string        `mapstructure:"header"`  // 向上游传递剩余时间（毫秒）的请求头，如 X-Request-Timeout，为空不传递
+}
+
+// RewriteConfig 转发前的路径与查询参数改写，在节点 is_remove_prex 之后按
+// replace_prefix、regex、add_prefix 的顺序改写路径
+type RewriteConfig struct {
+	ReplacePrefix PrefixRewriteConfig `mapstructure:"replace_prefix"` // 前缀替换
+	Regex         string              `mapstructure:"regex"`          // 路径正则，如 ^/api/v1/(.*)
+	Replacement   string              `mapstructure:"replacement"`    // 正则命中时的替换结果，支持 $1 引用捕获组，如 /v2/$1
+	AddPrefix     string              `mapstructure:"add_prefix"`     // 在路径前追加的前缀
+	Query         QueryRewriteConfig  `mapstructure:"query"`          // 查询参数改写
+}
+
+// PrefixRewriteConfig 按路径段将 from 前缀替换为 to
+type PrefixRewriteConfig struct {
+	From string `mapstructure:"from"` // 被替换的前缀，为空时取路由 path；from 与 to 都为空时不替换
+	To   string `mapstructure:"to"`   // 新前缀，为空时只移除前缀
+}
+
+// QueryRewriteConfig 查询参数改写，按 remove、rename、add 的顺序执行。
+// 参数名区分大小写，使用列表而非 map 避免配置加载时 key 被转为小写
+type QueryRewriteConfig struct {
+	Remove []string            `mapstructure:"remove"` // 删除参数
+	Rename []QueryRenameConfig `mapstructure:"rename"` // 参数改名
+	Add    []QueryParamConfig  `mapstructure:"add"`    // 追加参数，已存在时覆盖
+}
+
+// QueryRenameConfig 查询参数 from 改名为 to，to 已存在时被覆盖
+type QueryRenameConfig struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// QueryParamConfig 查询参数
+type QueryParamConfig struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
+}
+
+// TransportConfig 上游连接参数，每个实例独立连接池
+type TransportConfig struct {
+	MaxIdleConns          int           `mapstructure:"max_idle_conns"`          // 每个实例最大空闲连接数，默认 100
+	MaxConns              int           `mapstructure:"max_conns"`               // 每个实例最大连接数，为空不限制
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`       // 空闲连接超时，默认 90s
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`            // 建连超时，默认 30s
+	KeepAlive             time.Duration `mapstructure:"keep_alive"`              // TCP keep-alive 间隔，默认 30s
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`   // TLS 握手超时，默认 10s
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"` // 等待响应头超时，为空不限制
+	HTTP2                 *bool         `mapstructure:"http2"`                   // https 上游是否协商 HTTP/2，默认是
+	H2C                   bool          `mapstructure:"h2c"`                     // http 上游使用明文 HTTP/2（需上游支持 prior knowledge）
+}
+
+// HashKeyConfig 一致性哈希键来源
+type HashKeyConfig struct {
+	Source string `mapstructure:"source"` // ip | header | query | cookie | claim
+	Name   string `mapstructure:"name"`   // 请求头名、查询参数名、cookie 名或 JWT claim 名（如 user_id）
+}
+
+// TargetGroupConfig 命名目标组，每组独立负载均衡与健康检查
+type TargetGroupConfig struct {
+	Name    string                `mapstructure:"name"`    // 组名，如 stable、canary
+	Percent int                   `mapstructure:"percent"` // 流量比例，按占所有组之和的比例分流
+	Targets []RouterTargetsConfig `mapstructure:"targets"` // 目标地址
+}
+
+// MatchRuleConfig 灰度规则，按配置顺序匹配，条件全部满足时转发到规则自己的 targets
+type MatchRuleConfig struct {
+	Name       string                `mapstructure:"name"`       // 规则名称，用于日志
+	Predicates []PredicateConfig     `mapstructure:"predicates"` // 匹配条件，需全部满足
+	Targets    []RouterTargetsConfig `mapstructure:"targets"`    // 命中后转发的目标地址
+}
+
+// PredicateConfig 单个匹配条件，equals 与 regex 都为空时只要求取值非空
+type PredicateConfig struct {
+	Source string `mapstructure:"source"` // ip | header | query | cookie | claim
+	Name   string `mapstructure:"name"`   // 请求头名、查询参数名、cookie 名或 JWT claim 名（如 user_type）
+	Equals string `mapstructure:"equals"` // 取值等于
+	Regex  string `mapstructure:"regex"`  // 取值匹配正则
+}
+
+// FailureConfig 熔断失败判定规则
+type FailureConfig struct {
+	StatusCodes   []int `mapstructure:"status_codes"`   // 计为失败的状态码，为空时所有 5xx 均为失败
+	ConnectErrors *bool `mapstructure:"connect_errors"` // 连接失败是否计为失败，默认是
+	TimeoutErrors *bool `mapstructure:"timeout_errors"` // 上游超时是否计为失败，默认是
+}
+
+// FallbackConfig 熔断或无可用实例时的降级配置
+type FallbackConfig struct {
+	Type          string            `mapstructure:"type"`            // static | route | cache，为空不降级
+	Status        int               `mapstructure:"status"`          // static 响应码，默认 503
+	Headers       map[string]string `mapstructure:"headers"`         // static 响应头
+	ContentType   string            `mapstructure:"content_type"`    // static 内容类型，默认 application/json
+	Body          string            `mapstructure:"body"`            // static 响应体
+	Route         string            `mapstructure:"route"`           // route 模式转发到的路由名称
+	CacheTTL      time.Duration     `mapstructure:"cache_ttl"`       // cache 模式缓存有效期，默认 5m
+	CacheMaxBytes int               `mapstructure:"cache_max_bytes"` // cache 模式单个响应最大缓存字节数，默认 1MB
 }
 
 type RouterTargetsConfig struct {
@@ -39,7 +238,46 @@ type RouterTargetsConfig struct {
 	Protocol     string `mapstructure:"protocol"`       // 协议 http
 	Weight       int    `mapstructure:"weight"`         // 权重
 	IsRemovePrex bool   `mapstructure:"is_remove_prex"` // 是否移除前缀
+
+	TLS *UpstreamTLSConfig `mapstructure:"tls"` // 实例级 TLS 配置，非空字段覆盖路由配置
+}
+
+// UpstreamTLSConfig 上游 TLS 客户端配置，证书均为 PEM 文件
+type UpstreamTLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`              // 校验上游证书的 CA，为空使用系统根证书
+	CertFile           string `mapstructure:"cert_file"`            // 双向认证客户端证书
+	KeyFile            string `mapstructure:"key_file"`             // 双向认证客户端私钥
+	ServerName         string `mapstructure:"server_name"`          // SNI 及证书校验使用的域名，为空取目标地址
+	MinVersion         string `mapstructure:"min_version"`          // 最低 TLS 版本 1.0 | 1.1 | 1.2 | 1.3
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // 跳过证书校验，仅用于测试
 }
+
+// Merge 实例配置中非空字段覆盖路由配置
+func (c UpstreamTLSConfig) Merge(o *UpstreamTLSConfig) UpstreamTLSConfig {
+	if o == nil {
+		return c
+	}
+	if o.CAFile != "" {
+		c.CAFile = o.CAFile
+	}
+	if o.CertFile != "" {
+		c.CertFile = o.CertFile
+	}
+	if o.KeyFile != "" {
+		c.KeyFile = o.KeyFile
+	}
+	if o.ServerName != "" {
+		c.ServerName = o.ServerName
+	}
+	if o.MinVersion != "" {
+		c.MinVersion = o.MinVersion
+	}
+	if o.InsecureSkipVerify {
+		c.InsecureSkipVerify = true
+	}
+	return c
+}
+
 type JWT struct {
 	Enabled    bool        `mapstructure:"enabled"`     // 是否启用
 	Algorithm  string      `mapstructure:"algorithm"`   // HS256 / ES256
@@ -89,18 +327,89 @@ type InterceptGlobalConfig struct {
 }
 
 type Breaker struct {
-	Enabled          bool          `mapstructure:"enabled"`            // 熔断器是否开启
-	MaxRequests      uint32        `mapstructure:"max_requests"`       // 半开时最大探测请求数
-	Interval         time.Duration `mapstructure:"interval"`           // 统计窗口
-	Timeout          time.Duration `mapstructure:"timeout"`            // 熔断后多久进入半开
-	ErrorPercent     float64       `mapstructure:"error_percent"`      // 错误率阈值（0-100）
-	MinRequestAmount uint32        `mapstructure:"min_request_amount"` // 最小请求数才触发错误率计算
+	Enabled           bool          `mapstructure:"enabled"`             // 熔断器是否开启
+	MaxRequests       uint32        `mapstructure:"max_requests"`        // 半开时最大探测请求数
+	Interval          time.Duration `mapstructure:"interval"`            // 统计窗口
+	Buckets           int           `mapstructure:"buckets"`             // 统计窗口桶数
+	Timeout           time.Duration `mapstructure:"timeout"`             // 熔断后多久进入半开
+	ErrorPercent      float64       `mapstructure:"error_percent"`       // 错误率阈值（0-100）
+	MinRequestAmount  uint32        `mapstructure:"min_request_amount"`  // 最小请求数才触发错误率计算
+	PerInstance       bool          `mapstructure:"per_instance"`        // 是否按后端实例独立熔断
+	SlowCallThreshold time.Duration `mapstructure:"slow_call_threshold"` // 慢调用耗时阈值
+	SlowCallPercent   float64       `mapstructure:"slow_call_percent"`   // 慢调用率阈值（0-100），0 不启用
+	Mode              string        `mapstructure:"mode"`                // classic 状态机 | sre 自适应限流
+	K                 float64       `mapstructure:"k"`                   // sre 模式倍率，默认 1.5
+}
+
+// RouteBreaker 路由级熔断配置，未设置（零值）的字段继承全局 breaker
+type RouteBreaker struct {
+	Enabled           *bool         `mapstructure:"enabled"`             // 熔断器是否开启
+	MaxRequests       uint32        `mapstructure:"max_requests"`        // 半开时最大探测请求数
+	Interval          time.Duration `mapstructure:"interval"`            // 统计窗口
+	Buckets           int           `mapstructure:"buckets"`             // 统计窗口桶数
+	Timeout           time.Duration `mapstructure:"timeout"`             // 熔断后多久进入半开
+	ErrorPercent      float64       `mapstructure:"error_percent"`       // 错误率阈值（0-100）
+	MinRequestAmount  uint32        `mapstructure:"min_request_amount"`  // 最小请求数才触发错误率计算
+	PerInstance       *bool         `mapstructure:"per_instance"`        // 是否按后端实例独立熔断
+	SlowCallThreshold time.Duration `mapstructure:"slow_call_threshold"` // 慢调用耗时阈值
+	SlowCallPercent   float64       `mapstructure:"slow_call_percent"`   // 慢调用率阈值（0-100）
+	Mode              string        `mapstructure:"mode"`                // classic 状态机 | sre 自适应限流
+	K                 float64       `mapstructure:"k"`                   // sre 模式倍率
+}
+
+// Merge 用路由级配置覆盖全局配置
+func (b Breaker) Merge(o RouteBreaker) Breaker {
+	if o.Enabled != nil {
+		b.Enabled = *o.Enabled
+	}
+	if o.MaxRequests != 0 {
+		b.MaxRequests = o.MaxRequests
+	}
+	if o.Interval != 0 {
+		b.Interval = o.Interval
+	}
+	if o.Buckets != 0 {
+		b.Buckets = o.Buckets
+	}
+	if o.Timeout != 0 {
+		b.Timeout = o.Timeout
+	}
+	if o.ErrorPercent != 0 {
+		b.ErrorPercent = o.ErrorPercent
+	}
+	if o.MinRequestAmount != 0 {
+		b.MinRequestAmount = o.MinRequestAmount
+	}
+	if o.PerInstance != nil {
+		b.PerInstance = *o.PerInstance
+	}
+	if o.SlowCallThreshold != 0 {
+		b.SlowCallThreshold = o.SlowCallThreshold
+	}
+	if o.SlowCallPercent != 0 {
+		b.SlowCallPercent = o.SlowCallPercent
+	}
+	if o.Mode != "" {
+		b.Mode = o.Mode
+	}
+	if o.K != 0 {
+		b.K = o.K
+	}
+	return b
+}
+
+// AdminConfig 管理接口，独立端口监听，不经过网关鉴权中间件
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否开启
+	Port    int    `mapstructure:"port"`    // 监听端口
+	Token   string `mapstructure:"token"`   // 访问令牌，开启时必填，要求 Authorization: Bearer <token>
 }
 
 var (
-	once sync.Once    // 配置单例
-	cfg  Cfg          // 配置
-	mu   sync.RWMutex // 读写锁
+	once      sync.Once    // 配置单例
+	cfg       Cfg          // 配置
+	mu        sync.RWMutex // 读写锁
+	listeners []func(Cfg)  // 热更新回调
 )
 
 // Get 读取当前配置（并发安全）
@@ -110,6 +419,13 @@ func Get() Cfg {
 	return cfg
 }
 
+// OnChange 注册配置热更新回调，回调在新配置生效后按注册顺序执行
+func OnChange(fn func(Cfg)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, fn)
+}
+
 const (
 	// pre
 	cmdPre = "GW"
@@ -143,16 +459,23 @@ func Load(path string) {
 		// 热加载
 		viper.WatchConfig()
 		viper.OnConfigChange(func(in fsnotify.Event) {
-			mu.Lock()
-			if err := viper.Unmarshal(&cfg); err != nil {
+			// 解析到新对象，避免旧配置中已删除的字段残留
+			var newCfg Cfg
+			if err := viper.Unmarshal(&newCfg); err != nil {
 				logger.Errorf("reload config error: %v", err)
-			} else {
-				wlogging.SetGlobalLogLevel(cfg.Server.LogLevel)
-				ret, _ := json.MarshalIndent(cfg, "", "  ")
-				logger.Debugf("config: %v", string(ret))
-				logger.Info("config reloaded")
+				return
 			}
+			mu.Lock()
+			cfg = newCfg
+			fns := append([]func(Cfg){}, listeners...)
 			mu.Unlock()
+			wlogging.SetGlobalLogLevel(newCfg.Server.LogLevel)
+			ret, _ := json.MarshalIndent(newCfg, "", "  ")
+			logger.Debugf("config: %v", string(ret))
+			logger.Info("config reloaded")
+			for _, fn := range fns {
+				fn(newCfg)
+			}
 		})
 	})
 }
@@ -160,3 +483,15 @@ func Load(path string) {
 func GetWebServerAddress(cfg Cfg) string {
 	return fmt.Sprintf(":%d", cfg.Server.Port)
 }
+
+func GetTLSServerAddress(cfg Cfg) string {
+	port := cfg.Server.TLS.Port
+	if port == 0 {
+		port = 8443
+	}
+	return fmt.Sprintf(":%d", port)
+}
+
+func GetAdminServerAddress(cfg Cfg) string {
+	return fmt.Sprintf(":%d", cfg.Admin.Port)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerMerge(t *testing.T) {
+	yes, no := true, false
+	global := Breaker{
+		Enabled:          true,
+		MaxRequests:      5,
+		Interval:         10 * time.Second,
+		Timeout:          30 * time.Second,
+		ErrorPercent:     50,
+		MinRequestAmount: 10,
+		PerInstance:      true,
+		Mode:             "classic",
+	}
+	cases := []struct {
+		name  string
+		route RouteBreaker
+		want  func(b Breaker) Breaker
+	}{
+		{"empty inherits global", RouteBreaker{}, func(b Breaker) Breaker { return b }},
+		{"non-zero fields override", RouteBreaker{MaxRequests: 1, Interval: time.Minute, Buckets: 20, MinRequestAmount: 3},
+			func(b Breaker) Breaker {
+				b.MaxRequests, b.Interval, b.Buckets, b.MinRequestAmount = 1, time.Minute, 20, 3
+				return b
+			}},
+		{"mode fields override", RouteBreaker{Timeout: time.Second, ErrorPercent: 20, Mode: "sre", K: 2},
+			func(b Breaker) Breaker {
+				b.Timeout, b.ErrorPercent, b.Mode, b.K = time.Second, 20, "sre", 2
+				return b
+			}},
+		{"explicit false overrides", RouteBreaker{Enabled: &no, PerInstance: &no},
+			func(b Breaker) Breaker {
+				b.Enabled, b.PerInstance = false, false
+				return b
+			}},
+		{"explicit true keeps", RouteBreaker{Enabled: &yes, SlowCallThreshold: time.Second, SlowCallPercent: 30},
+			func(b Breaker) Breaker {
+				b.SlowCallThreshold, b.SlowCallPercent = time.Second, 30
+				return b
+			}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := global.Merge(tc.route), tc.want(global); got != want {
+				t.Fatalf("Merge = %+v, want %+v", got, want)
+			}
+		})
+	}
+	// 全局关闭时路由可单独开启
+	if got := (Breaker{}).Merge(RouteBreaker{Enabled: &yes}); !got.Enabled {
+		t.Fatal("route enabled not applied over disabled global")
+	}
+}
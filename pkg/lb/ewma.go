@@ -0,0 +1,56 @@
+package lb
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// p2cEWMA 随机选两个节点，取 peak EWMA 延迟 × (在途请求+1) / 权重 较小者，
+// 对慢节点和请求成本不均的后端更敏感
+type p2cEWMA struct {
+	pool *nodePool
+}
+
+func NewP2CEWMA(insts []Instance) Balancer {
+	return &p2cEWMA{pool: newNodePool(insts)}
+}
+
+func (p *p2cEWMA) Pick(info PickInfo) (Instance, bool) {
+	nodes := p.pool.list()
+	if len(info.Exclude) > 0 {
+		nodes = excludeNodes(nodes, info.Exclude)
+	}
+	var n *node
+	switch len(nodes) {
+	case 0:
+		return Instance{}, false
+	case 1:
+		n = nodes[0]
+	default:
+		i := rand.IntN(len(nodes))
+		j := rand.IntN(len(nodes) - 1)
+		if j >= i {
+			j++
+		}
+		a, b := nodes[i], nodes[j]
+		n = a
+		if cost(b) < cost(a) {
+			n = b
+		}
+	}
+	n.inflight.Add(1)
+	return n.inst, true
+}
+
+// cost 节点负载估计，尚无延迟样本的节点优先
+func cost(n *node) float64 {
+	return (n.latency() + 1) * float64(n.inflight.Load()+1) / float64(n.inst.Weight)
+}
+
+func (p *p2cEWMA) Done(inst Instance, latency time.Duration, err error) {
+	p.pool.done(inst, latency, err)
+}
+
+func (p *p2cEWMA) Update(insts []Instance) {
+	p.pool.update(insts)
+}
@@ -0,0 +1,96 @@
+package lb
+
+import (
+	"hash/crc32"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// 每单位权重的虚拟节点数
+const virtualReplicas = 160
+
+// hashRing 一致性哈希环快照
+type hashRing struct {
+	hashes []uint32    // 有序虚拟节点哈希
+	owners []*Instance // 与 hashes 一一对应的真实节点
+	insts  []Instance
+}
+
+// consistentHash 一致性哈希：节点摘除或恢复时只影响其虚拟节点覆盖的键
+type consistentHash struct {
+	ring atomic.Pointer[hashRing]
+}
+
+func NewConsistentHash(insts []Instance) Balancer {
+	h := &consistentHash{}
+	h.Update(insts)
+	return h
+}
+
+func (h *consistentHash) Pick(info PickInfo) (Instance, bool) {
+	r := h.ring.Load()
+	if len(r.hashes) == 0 {
+		return Instance{}, false
+	}
+	// 取不到键时随机选择，避免所有无键请求落到同一节点
+	if info.HashKey == "" {
+		insts := r.insts
+		if len(info.Exclude) > 0 {
+			insts = make([]Instance, 0, len(r.insts))
+			for _, inst := range r.insts {
+				if !info.Exclude[inst.Addr] {
+					insts = append(insts, inst)
+				}
+			}
+			if len(insts) == 0 {
+				return Instance{}, false
+			}
+		}
+		return insts[rand.IntN(len(insts))], true
+	}
+	key := crc32.ChecksumIEEE([]byte(info.HashKey))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= key })
+	// 顺时针找到第一个未被排除的节点，重试时键会稳定落到下一个节点
+	for n := 0; n < len(r.hashes); n++ {
+		owner := r.owners[(i+n)%len(r.hashes)]
+		if !info.Exclude[owner.Addr] {
+			return *owner, true
+		}
+	}
+	return Instance{}, false
+}
+
+func (h *consistentHash) Done(Instance, time.Duration, error) {}
+
+func (h *consistentHash) Update(insts []Instance) {
+	r := &hashRing{}
+	type vnode struct {
+		hash  uint32
+		owner *Instance
+	}
+	var vnodes []vnode
+	for _, inst := range insts {
+		if inst.Weight <= 0 {
+			continue
+		}
+		owner := inst
+		r.insts = append(r.insts, inst)
+		for i := 0; i < virtualReplicas*inst.Weight; i++ {
+			vnodes = append(vnodes, vnode{
+				hash:  crc32.ChecksumIEEE([]byte(inst.Addr + "#" + strconv.Itoa(i))),
+				owner: &owner,
+			})
+		}
+	}
+	sort.Slice(vnodes, func(i, j int) bool { return vnodes[i].hash < vnodes[j].hash })
+	r.hashes = make([]uint32, len(vnodes))
+	r.owners = make([]*Instance, len(vnodes))
+	for i, v := range vnodes {
+		r.hashes[i] = v.hash
+		r.owners[i] = v.owner
+	}
+	h.ring.Store(r)
+}
@@ -0,0 +1,61 @@
+package lb
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestConsistentHashRemapping(t *testing.T) {
+	pool := []Instance{
+		{Addr: "10.0.0.1:80", Weight: 1},
+		{Addr: "10.0.0.2:80", Weight: 1},
+		{Addr: "10.0.0.3:80", Weight: 1},
+	}
+	b := NewConsistentHash(pool)
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		inst, ok := b.Pick(PickInfo{HashKey: key})
+		if !ok {
+			t.Fatal("pick failed")
+		}
+		before[key] = inst.Addr
+	}
+
+	// 摘除一个节点，只有原本落在该节点的键需要迁移
+	b.Update(pool[:2])
+	for key, addr := range before {
+		inst, _ := b.Pick(PickInfo{HashKey: key})
+		if addr != pool[2].Addr && inst.Addr != addr {
+			t.Fatalf("key %s moved from %s to %s", key, addr, inst.Addr)
+		}
+		if inst.Addr == pool[2].Addr {
+			t.Fatalf("key %s still mapped to removed instance", key)
+		}
+	}
+
+	// 节点恢复后映射回到原来的节点
+	b.Update(pool)
+	for key, addr := range before {
+		if inst, _ := b.Pick(PickInfo{HashKey: key}); inst.Addr != addr {
+			t.Fatalf("key %s mapped to %s after restore, want %s", key, inst.Addr, addr)
+		}
+	}
+}
+
+func TestConsistentHashBalance(t *testing.T) {
+	b := NewConsistentHash([]Instance{
+		{Addr: "10.0.0.1:80", Weight: 1},
+		{Addr: "10.0.0.2:80", Weight: 1},
+	})
+	count := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		inst, _ := b.Pick(PickInfo{HashKey: strconv.Itoa(i)})
+		count[inst.Addr]++
+	}
+	for addr, n := range count {
+		if n < 4000 || n > 6000 {
+			t.Fatalf("instance %s got %d of 10000 keys", addr, n)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package lb
 
 import (
-	"net"
+	"context"
+	"math/rand/v2"
 	"sync"
 	"time"
 
@@ -10,60 +11,172 @@ import (
 
 var logger = wlogging.MustGetFileLoggerWithoutName(nil)
 
+const (
+	defaultCheckInterval = 5 * time.Second
+	defaultThreshold     = 1
+)
+
+// CheckOptions 健康检查参数
+type CheckOptions struct {
+	Interval           time.Duration // 检测间隔，默认 5s
+	Timeout            time.Duration // 单次探测超时，默认 3s
+	Jitter             time.Duration // 每轮间隔额外增加 [0, Jitter) 的随机时长，避免多实例同时探测
+	HealthyThreshold   int           // 连续成功多少次恢复，默认 1
+	UnhealthyThreshold int           // 连续失败多少次摘除，默认 1
+	Prober             Prober        // 探测方式，默认 TCP
+}
+
+// checkState 单个节点的探测状态
+type checkState struct {
+	healthy   bool
+	successes int // 连续成功次数
+	failures  int // 连续失败次数
+}
+
 // HealthChecker 简易 TCP/HTTP 探活
+// 首轮探测结果直接决定初始状态，之后按 rise/fall 阈值切换
 type HealthChecker struct {
-	interval time.Duration // 检测间隔
-	balancer Balancer      // 负载均衡
+	opts     CheckOptions
+	balancer Balancer // 负载均衡
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // 停止探测
+	done   chan struct{}      // 探测协程已退出
 }
 
-func NewHealthChecker(b Balancer, interval time.Duration) *HealthChecker {
-	return &HealthChecker{balancer: b, interval: interval}
+func NewHealthChecker(b Balancer, opts CheckOptions) *HealthChecker {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultCheckInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultProbeTimeout
+	}
+	if opts.HealthyThreshold <= 0 {
+		opts.HealthyThreshold = defaultThreshold
+	}
+	if opts.UnhealthyThreshold <= 0 {
+		opts.UnhealthyThreshold = defaultThreshold
+	}
+	if opts.Prober == nil {
+		opts.Prober = TCPProber{}
+	}
+	return &HealthChecker{balancer: b, opts: opts}
+}
+
+// Start 启动探测，ctx 取消或调用 Stop 后退出
+func (h *HealthChecker) Start(ctx context.Context, inst []Instance) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return
+	}
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.done = make(chan struct{})
+	go h.run(ctx, inst)
 }
 
-func (h *HealthChecker) Start(inst []Instance) {
-	go func() {
-		for {
-			healthy := h.checkAll(inst)
+// Stop 停止探活并等待探测协程退出，路由下线时调用
+func (h *HealthChecker) Stop() {
+	h.mu.Lock()
+	cancel, done := h.cancel, h.done
+	h.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (h *HealthChecker) run(ctx context.Context, inst []Instance) {
+	defer close(h.done)
+	states := make([]*checkState, len(inst))
+	var last []Instance
+	for round := 0; ; round++ {
+		results := h.checkAll(ctx, inst)
+		if ctx.Err() != nil {
+			return
+		}
+		healthy := make([]Instance, 0, len(inst))
+		for i, err := range results {
+			if states[i] == nil {
+				states[i] = &checkState{healthy: err == nil}
+			} else {
+				h.transit(inst[i], states[i], err)
+			}
+			if states[i].healthy {
+				healthy = append(healthy, inst[i])
+			}
+		}
+		if round == 0 || !sameInstances(last, healthy) {
 			h.balancer.Update(healthy)
-			time.Sleep(h.interval)
 			logger.Debugf("[HealthChecker] update healthy instances: %v", healthy)
+			last = healthy
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.nextDelay()):
 		}
-	}()
+	}
 }
 
-func (h *HealthChecker) checkAll(inst []Instance) []Instance {
+// transit 按连续成功/失败次数切换节点状态
+func (h *HealthChecker) transit(inst Instance, st *checkState, err error) {
+	if err == nil {
+		st.failures = 0
+		st.successes++
+		if !st.healthy && st.successes >= h.opts.HealthyThreshold {
+			st.healthy = true
+			logger.Infof("[HealthChecker] %s healthy after %d successes", inst.Addr, st.successes)
+		}
+		return
+	}
+	st.successes = 0
+	st.failures++
+	if st.healthy && st.failures >= h.opts.UnhealthyThreshold {
+		st.healthy = false
+		logger.Warnf("[HealthChecker] %s unhealthy after %d failures: %v", inst.Addr, st.failures, err)
+	}
+}
+
+func (h *HealthChecker) nextDelay() time.Duration {
+	if h.opts.Jitter <= 0 {
+		return h.opts.Interval
+	}
+	return h.opts.Interval + rand.N(h.opts.Jitter)
+}
+
+// checkAll 并发探测全部节点，返回与 inst 一一对应的结果
+func (h *HealthChecker) checkAll(ctx context.Context, inst []Instance) []error {
 	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var healthy []Instance
+	results := make([]error, len(inst))
 	logger.Debug("start health check")
-	for _, v := range inst {
+	for i, v := range inst {
 		wg.Add(1)
-		go func(in Instance) {
+		go func(i int, in Instance) {
 			defer wg.Done()
-			if h.ok(in.Addr) {
-				logger.Debugf("check ok: %s", in.Addr)
-				mu.Lock()
-				healthy = append(healthy, in)
-				mu.Unlock()
-			} else {
-				logger.Debugf("check failed: %s", in.Addr)
+			probeCtx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+			defer cancel()
+			if err := h.opts.Prober.Probe(probeCtx, in); err != nil {
+				logger.Debugf("check failed: %s, %v", in.Addr, err)
+				results[i] = err
+				return
 			}
-		}(v)
+			logger.Debugf("check ok: %s", in.Addr)
+		}(i, v)
 	}
 	wg.Wait()
-	return healthy
+	return results
 }
 
-func (h *HealthChecker) ok(addr string) bool {
-	return isPortOpen(addr)
-}
-
-// isPortOpen 检测端口是否开放
-func isPortOpen(addr string) bool {
-	conn, err := net.Dial("tcp", addr)
-	if err != nil {
+func sameInstances(a, b []Instance) bool {
+	if len(a) != len(b) {
 		return false
 	}
-	conn.Close()
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
 	return true
 }
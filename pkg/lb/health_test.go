@@ -0,0 +1,84 @@
+package lb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptProber 按预设结果序列返回探测结果
+type scriptProber struct {
+	mu      sync.Mutex
+	results []error
+	calls   chan struct{}
+}
+
+func (p *scriptProber) Probe(ctx context.Context, inst Instance) error {
+	p.mu.Lock()
+	var err error
+	if len(p.results) > 0 {
+		err, p.results = p.results[0], p.results[1:]
+	}
+	p.mu.Unlock()
+	p.calls <- struct{}{}
+	return err
+}
+
+// recordBalancer 记录每次 Update 的节点数
+type recordBalancer struct {
+	Balancer
+	updates chan int
+}
+
+func (b *recordBalancer) Update(insts []Instance) {
+	b.updates <- len(insts)
+}
+
+func TestHealthCheckerThresholds(t *testing.T) {
+	down := errors.New("down")
+	prober := &scriptProber{
+		// 首轮健康；连续 2 次失败后摘除；连续 2 次成功后恢复
+		results: []error{nil, down, down, nil, nil},
+		calls:   make(chan struct{}, 16),
+	}
+	b := &recordBalancer{updates: make(chan int, 16)}
+	h := NewHealthChecker(b, CheckOptions{
+		Interval:           time.Millisecond,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+		Prober:             prober,
+	})
+	h.Start(context.Background(), []Instance{{Addr: "a", Weight: 1}})
+	defer h.Stop()
+
+	want := []int{1, 0, 1}
+	for i, n := range want {
+		select {
+		case got := <-b.updates:
+			if got != n {
+				t.Fatalf("update %d: %d instances, want %d", i, got, n)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("update %d not received", i)
+		}
+	}
+}
+
+func TestHealthCheckerStop(t *testing.T) {
+	prober := &scriptProber{calls: make(chan struct{}, 1024)}
+	b := &recordBalancer{updates: make(chan int, 16)}
+	h := NewHealthChecker(b, CheckOptions{Interval: time.Millisecond, Prober: prober})
+	h.Start(context.Background(), []Instance{{Addr: "a", Weight: 1}})
+	<-b.updates
+	h.Stop()
+	// Stop 返回后不再探测
+	for len(prober.calls) > 0 {
+		<-prober.calls
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := len(prober.calls); n != 0 {
+		t.Fatalf("%d probes after Stop", n)
+	}
+}
@@ -1,15 +1,71 @@
 package lb
 
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSkipped 节点已选出但请求未发出（如被熔断），Done 时只释放在途计数
+var ErrSkipped = errors.New("request skipped")
+
+const (
+	StrategyRoundRobin = "round_robin"     // 平滑加权轮询（默认）
+	StrategyLeastConn  = "least_conn"      // 最少在途请求
+	StrategyP2CEWMA    = "p2c_ewma"        // 随机两选一 + peak EWMA 延迟
+	StrategyHash       = "consistent_hash" // 一致性哈希，会话保持
+)
+
 // Instance 后端节点
 type Instance struct {
 	Addr         string // 后端地址
 	Protocol     string // 协议
 	Weight       int    // 权重
 	IsRemovePrex bool   // 是否移除前缀
+
+	TLS *tls.Config // https 节点的 TLS 客户端配置，为空使用系统默认
+}
+
+// URL 节点访问地址 protocol://addr
+func (i Instance) URL() string {
+	protocol := i.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+	return protocol + "://" + i.Addr
+}
+
+// PickInfo 选择节点时的请求信息
+type PickInfo struct {
+	HashKey string          // 一致性哈希键，其它策略忽略
+	Exclude map[string]bool // 需要避开的节点地址（如重试时已失败的节点），全部被排除时 Pick 失败
 }
 
 // Balancer 负载均衡器
 type Balancer interface {
-	Pick() (string, bool, bool) // 返回选中的 addr
-	Update([]Instance)          // 批量更新节点
+	Pick(info PickInfo) (Instance, bool)                  // 选择节点，视为请求开始
+	Done(inst Instance, latency time.Duration, err error) // 请求结束回调，每次成功 Pick 后必须调用
+	Update([]Instance)                                    // 批量更新节点
+}
+
+// Stopper 持有定时器等后台资源的 Balancer 实现，不再使用时调用 Stop 释放
+type Stopper interface {
+	Stop()
+}
+
+// NewStrategy 按策略创建负载均衡器，策略为空时使用加权轮询
+func NewStrategy(strategy string, insts []Instance) (Balancer, error) {
+	switch strategy {
+	case "", StrategyRoundRobin:
+		return New(insts), nil
+	case StrategyLeastConn:
+		return NewLeastConn(insts), nil
+	case StrategyP2CEWMA:
+		return NewP2CEWMA(insts), nil
+	case StrategyHash:
+		return NewConsistentHash(insts), nil
+	default:
+		return nil, fmt.Errorf("unknown lb strategy: %s", strategy)
+	}
 }
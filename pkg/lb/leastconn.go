@@ -0,0 +1,50 @@
+package lb
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// leastConn 选择 在途请求数/权重 最小的节点，相同时从随机位置开始避免扎堆
+type leastConn struct {
+	pool *nodePool
+}
+
+func NewLeastConn(insts []Instance) Balancer {
+	return &leastConn{pool: newNodePool(insts)}
+}
+
+func (l *leastConn) Pick(info PickInfo) (Instance, bool) {
+	nodes := l.pool.list()
+	if len(nodes) == 0 {
+		return Instance{}, false
+	}
+	offset := rand.IntN(len(nodes))
+	var (
+		best      *node
+		bestScore float64
+	)
+	for i := range nodes {
+		n := nodes[(offset+i)%len(nodes)]
+		if info.Exclude[n.inst.Addr] {
+			continue
+		}
+		score := float64(n.inflight.Load()+1) / float64(n.inst.Weight)
+		if best == nil || score < bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return Instance{}, false
+	}
+	best.inflight.Add(1)
+	return best.inst, true
+}
+
+func (l *leastConn) Done(inst Instance, latency time.Duration, err error) {
+	l.pool.done(inst, latency, err)
+}
+
+func (l *leastConn) Update(insts []Instance) {
+	l.pool.update(insts)
+}
@@ -0,0 +1,165 @@
+package lb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConsecutiveErrors  = 5
+	defaultBaseEjectionTime   = 30 * time.Second
+	defaultMaxEjectionTime    = 300 * time.Second
+	defaultMaxEjectionPercent = 50
+)
+
+// OutlierOptions 被动健康检查参数
+type OutlierOptions struct {
+	ConsecutiveErrors  int           // 连续失败多少次驱逐，默认 5
+	BaseEjectionTime   time.Duration // 首次驱逐时长，之后每次驱逐翻倍，默认 30s
+	MaxEjectionTime    time.Duration // 单次驱逐时长上限，默认 300s
+	MaxEjectionPercent int           // 同时被驱逐节点的最大比例（0-100），默认 50
+}
+
+// outlierStat 单个节点的被动检查状态
+type outlierStat struct {
+	consecutive  int         // 连续失败次数
+	ejections    int         // 累计驱逐次数，决定下次驱逐时长
+	ejectedUntil time.Time   // 驱逐截止时间
+	restoredAt   time.Time   // 上次恢复时间
+	restore      *time.Timer // 驱逐到期后的恢复定时器
+}
+
+// outlierDetector 包装 Balancer：根据真实流量结果驱逐连续失败的节点，
+// 驱逐时长指数增长；主动探活的结果经 Update 传入，驱逐在其基础上生效
+type outlierDetector struct {
+	inner Balancer
+	opts  OutlierOptions
+
+	mu      sync.Mutex
+	healthy []Instance              // 主动探活认为健康的节点
+	stats   map[string]*outlierStat // addr -> 状态
+	stopped bool                    // 已停止，不再恢复被驱逐的节点
+}
+
+func NewOutlierDetector(inner Balancer, opts OutlierOptions) Balancer {
+	if opts.ConsecutiveErrors <= 0 {
+		opts.ConsecutiveErrors = defaultConsecutiveErrors
+	}
+	if opts.BaseEjectionTime <= 0 {
+		opts.BaseEjectionTime = defaultBaseEjectionTime
+	}
+	if opts.MaxEjectionTime <= 0 {
+		opts.MaxEjectionTime = defaultMaxEjectionTime
+	}
+	if opts.MaxEjectionPercent <= 0 {
+		opts.MaxEjectionPercent = defaultMaxEjectionPercent
+	}
+	return &outlierDetector{inner: inner, opts: opts, stats: make(map[string]*outlierStat)}
+}
+
+func (d *outlierDetector) Pick(info PickInfo) (Instance, bool) {
+	return d.inner.Pick(info)
+}
+
+func (d *outlierDetector) Done(inst Instance, latency time.Duration, err error) {
+	d.inner.Done(inst, latency, err)
+	if errors.Is(err, ErrSkipped) {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := d.stat(inst.Addr)
+	now := time.Now()
+	if err == nil {
+		st.consecutive = 0
+		return
+	}
+	if now.Before(st.ejectedUntil) {
+		return
+	}
+	st.consecutive++
+	if st.consecutive < d.opts.ConsecutiveErrors || !d.canEject(now) {
+		return
+	}
+	d.eject(inst.Addr, st, now)
+}
+
+func (d *outlierDetector) Update(insts []Instance) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.healthy = insts
+	d.apply(time.Now())
+}
+
+func (d *outlierDetector) stat(addr string) *outlierStat {
+	st, ok := d.stats[addr]
+	if !ok {
+		st = &outlierStat{}
+		d.stats[addr] = st
+	}
+	return st
+}
+
+// canEject 驱逐后被驱逐节点数不能超过比例上限，且至少保留一个节点
+func (d *outlierDetector) canEject(now time.Time) bool {
+	ejected := 0
+	for _, inst := range d.healthy {
+		if st, ok := d.stats[inst.Addr]; ok && now.Before(st.ejectedUntil) {
+			ejected++
+		}
+	}
+	total := len(d.healthy)
+	return ejected+1 < total && (ejected+1)*100 <= total*d.opts.MaxEjectionPercent
+}
+
+func (d *outlierDetector) eject(addr string, st *outlierStat, now time.Time) {
+	// 恢复后持续正常的时间越长，累计驱逐次数衰减越多
+	if !st.restoredAt.IsZero() {
+		decay := int(now.Sub(st.restoredAt) / d.opts.BaseEjectionTime)
+		st.ejections = max(0, st.ejections-decay)
+	}
+	st.ejections++
+	duration := d.opts.BaseEjectionTime << (st.ejections - 1)
+	if duration <= 0 || duration > d.opts.MaxEjectionTime {
+		duration = d.opts.MaxEjectionTime
+	}
+	st.consecutive = 0
+	st.ejectedUntil = now.Add(duration)
+	logger.Warnf("[Outlier] eject %s for %v, ejections=%d", addr, duration, st.ejections)
+	d.apply(now)
+	st.restore = time.AfterFunc(duration, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.stopped {
+			return
+		}
+		st.restoredAt = time.Now()
+		logger.Infof("[Outlier] restore %s", addr)
+		d.apply(st.restoredAt)
+	})
+}
+
+// Stop 取消未到期的恢复定时器，路由下线时调用
+func (d *outlierDetector) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	for _, st := range d.stats {
+		if st.restore != nil {
+			st.restore.Stop()
+		}
+	}
+}
+
+// apply 将未被驱逐的健康节点下发给内部均衡器
+func (d *outlierDetector) apply(now time.Time) {
+	insts := make([]Instance, 0, len(d.healthy))
+	for _, inst := range d.healthy {
+		if st, ok := d.stats[inst.Addr]; ok && now.Before(st.ejectedUntil) {
+			continue
+		}
+		insts = append(insts, inst)
+	}
+	d.inner.Update(insts)
+}
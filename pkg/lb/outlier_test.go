@@ -0,0 +1,100 @@
+package lb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errUpstream = errors.New("connect refused")
+
+func pickAll(b Balancer, n int) map[string]int {
+	count := make(map[string]int)
+	for i := 0; i < n; i++ {
+		inst, ok := b.Pick(PickInfo{})
+		if ok {
+			count[inst.Addr]++
+			b.Done(inst, 0, ErrSkipped)
+		}
+	}
+	return count
+}
+
+func TestOutlierEjectsAndCapsPercent(t *testing.T) {
+	pool := []Instance{{Addr: "a", Weight: 1}, {Addr: "b", Weight: 1}, {Addr: "c", Weight: 1}, {Addr: "d", Weight: 1}}
+	b := NewOutlierDetector(New(pool), OutlierOptions{
+		ConsecutiveErrors:  3,
+		BaseEjectionTime:   time.Hour,
+		MaxEjectionPercent: 50,
+	})
+	b.Update(pool)
+
+	fail := func(addr string, n int) {
+		for i := 0; i < n; i++ {
+			b.Done(Instance{Addr: addr}, time.Millisecond, errUpstream)
+		}
+	}
+	// 中间穿插成功会清零连续失败计数
+	fail("a", 2)
+	b.Done(Instance{Addr: "a"}, time.Millisecond, nil)
+	fail("a", 2)
+	if count := pickAll(b, 40); count["a"] == 0 {
+		t.Fatalf("a ejected without consecutive errors: %v", count)
+	}
+
+	fail("a", 3)
+	fail("b", 3)
+	fail("c", 3)
+	count := pickAll(b, 40)
+	if count["a"] != 0 || count["b"] != 0 {
+		t.Fatalf("a and b should be ejected: %v", count)
+	}
+	// 超过 50% 上限，c 不会被驱逐
+	if count["c"] == 0 || count["d"] == 0 {
+		t.Fatalf("c and d should remain: %v", count)
+	}
+
+	// 主动探活更新节点时驱逐依然生效
+	b.Update(pool)
+	if count := pickAll(b, 40); count["a"] != 0 {
+		t.Fatalf("a should stay ejected after Update: %v", count)
+	}
+}
+
+func TestOutlierRestoresAfterEjection(t *testing.T) {
+	pool := []Instance{{Addr: "a", Weight: 1}, {Addr: "b", Weight: 1}}
+	b := NewOutlierDetector(New(pool), OutlierOptions{
+		ConsecutiveErrors:  1,
+		BaseEjectionTime:   20 * time.Millisecond,
+		MaxEjectionPercent: 50,
+	})
+	b.Update(pool)
+	b.Done(Instance{Addr: "a"}, time.Millisecond, errUpstream)
+	if count := pickAll(b, 10); count["a"] != 0 {
+		t.Fatalf("a should be ejected: %v", count)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if count := pickAll(b, 10); count["a"] == 0 {
+		t.Fatalf("a should be restored: %v", count)
+	}
+}
+
+func TestOutlierStopCancelsRestore(t *testing.T) {
+	pool := []Instance{{Addr: "a", Weight: 1}, {Addr: "b", Weight: 1}}
+	b := NewOutlierDetector(New(pool), OutlierOptions{
+		ConsecutiveErrors:  1,
+		BaseEjectionTime:   20 * time.Millisecond,
+		MaxEjectionPercent: 50,
+	})
+	b.Update(pool)
+	b.Done(Instance{Addr: "a"}, time.Millisecond, errUpstream)
+	d := b.(*outlierDetector)
+	d.Stop()
+	if d.stats["a"].restore.Stop() {
+		t.Fatal("restore timer still pending after Stop")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if count := pickAll(b, 10); count["a"] != 0 {
+		t.Fatalf("a restored after Stop: %v", count)
+	}
+}
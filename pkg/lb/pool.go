@@ -0,0 +1,115 @@
+package lb
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ewmaDecay    = 10 * time.Second // EWMA 衰减时间常数
+	errorPenalty = time.Second      // 失败请求按至少该延迟计入
+)
+
+// node 节点快照，创建后只读；统计数据按地址跨 Update 共享，摘除后恢复时保留
+type node struct {
+	inst Instance
+	*stats
+}
+
+// stats 节点运行时统计
+type stats struct {
+	inflight atomic.Int64 // 在途请求数
+
+	mu    sync.Mutex
+	ewma  float64   // peak EWMA 延迟（纳秒）
+	stamp time.Time // 上次更新时间
+}
+
+// observe 记录一次请求延迟：高于均值时直接取峰值，否则按时间衰减平滑
+func (s *stats) observe(latency time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rtt := float64(latency)
+	if rtt > s.ewma || s.stamp.IsZero() {
+		s.ewma = rtt
+	} else {
+		w := math.Exp(-float64(now.Sub(s.stamp)) / float64(ewmaDecay))
+		s.ewma = s.ewma*w + rtt*(1-w)
+	}
+	s.stamp = now
+}
+
+func (s *stats) latency() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ewma
+}
+
+// nodePool 节点快照，Pick 无锁读取，Update 原子替换
+type nodePool struct {
+	nodes atomic.Pointer[[]*node]
+
+	mu     sync.RWMutex
+	byAddr map[string]*stats // 出现过的全部节点的统计
+}
+
+func newNodePool(insts []Instance) *nodePool {
+	p := &nodePool{byAddr: make(map[string]*stats)}
+	p.update(insts)
+	return p
+}
+
+func (p *nodePool) list() []*node {
+	return *p.nodes.Load()
+}
+
+// excludeNodes 过滤掉指定地址的节点，返回新切片
+func excludeNodes(nodes []*node, addrs map[string]bool) []*node {
+	kept := make([]*node, 0, len(nodes))
+	for _, n := range nodes {
+		if !addrs[n.inst.Addr] {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+func (p *nodePool) update(insts []Instance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodes := make([]*node, 0, len(insts))
+	for _, inst := range insts {
+		if inst.Weight <= 0 {
+			continue
+		}
+		st, ok := p.byAddr[inst.Addr]
+		if !ok {
+			st = &stats{}
+			p.byAddr[inst.Addr] = st
+		}
+		// 每次 Update 生成新节点，Pick 读取的旧快照不受影响
+		nodes = append(nodes, &node{inst: inst, stats: st})
+	}
+	p.nodes.Store(&nodes)
+}
+
+// done 请求结束
+func (p *nodePool) done(inst Instance, latency time.Duration, err error) {
+	p.mu.RLock()
+	n, ok := p.byAddr[inst.Addr]
+	p.mu.RUnlock()
+	if !ok {
+		return
+	}
+	n.inflight.Add(-1)
+	if errors.Is(err, ErrSkipped) {
+		return
+	}
+	if err != nil && latency < errorPenalty {
+		latency = errorPenalty
+	}
+	n.observe(latency, time.Now())
+}
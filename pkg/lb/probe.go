@@ -0,0 +1,150 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeTimeout = 3 * time.Second
+	maxProbeBody        = 64 << 10 // 响应体匹配最多读取 64KB
+	maxProbeDrain       = 4 << 10  // 关闭前最多丢弃的剩余响应体，读完才能复用连接
+)
+
+// Prober 单次探活，返回 nil 表示健康
+type Prober interface {
+	Probe(ctx context.Context, inst Instance) error // ctx 携带探测超时
+}
+
+// TCPProber 检测端口是否可连接
+type TCPProber struct{}
+
+func (TCPProber) Probe(ctx context.Context, inst Instance) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", inst.Addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// HTTPCheck HTTP 探活参数
+type HTTPCheck struct {
+	Method       string            // 请求方法，默认 GET
+	Path         string            // 探测路径，默认 /
+	Headers      map[string]string // 请求头，Host 会覆盖请求 Host
+	StatusMin    int               // 期望状态码下限，默认 200
+	StatusMax    int               // 期望状态码上限，默认 399
+	BodyContains string            // 响应体需包含的字符串
+	BodyRegex    *regexp.Regexp    // 响应体需匹配的正则
+}
+
+// HTTPProber 按节点 protocol 发起 HTTP(S) 请求，校验状态码与响应体
+type HTTPProber struct {
+	check   HTTPCheck
+	client  *http.Client
+	clients sync.Map // *tls.Config -> *http.Client，https 节点使用各自的 TLS 配置
+}
+
+func NewHTTPProber(check HTTPCheck) *HTTPProber {
+	if check.Method == "" {
+		check.Method = http.MethodGet
+	}
+	if check.Path == "" {
+		check.Path = "/"
+	}
+	if check.StatusMin == 0 && check.StatusMax == 0 {
+		check.StatusMin, check.StatusMax = 200, 399
+	}
+	return &HTTPProber{
+		check: check,
+		client: &http.Client{
+			// 重定向视为探测结果本身，不跟随
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+func (p *HTTPProber) Probe(ctx context.Context, inst Instance) error {
+	req, err := http.NewRequestWithContext(ctx, p.check.Method, inst.URL()+p.check.Path, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range p.check.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	resp, err := p.clientFor(inst).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxProbeDrain))
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < p.check.StatusMin || resp.StatusCode > p.check.StatusMax {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if p.check.BodyContains == "" && p.check.BodyRegex == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return err
+	}
+	if p.check.BodyContains != "" && !strings.Contains(string(body), p.check.BodyContains) {
+		return fmt.Errorf("body does not contain %q", p.check.BodyContains)
+	}
+	if p.check.BodyRegex != nil && !p.check.BodyRegex.Match(body) {
+		return fmt.Errorf("body does not match %q", p.check.BodyRegex.String())
+	}
+	return nil
+}
+
+// clientFor 节点配置了 TLS 时使用对应的客户端，与转发使用相同的证书
+func (p *HTTPProber) clientFor(inst Instance) *http.Client {
+	if inst.TLS == nil {
+		return p.client
+	}
+	if c, ok := p.clients.Load(inst.TLS); ok {
+		return c.(*http.Client)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = inst.TLS.Clone()
+	c, _ := p.clients.LoadOrStore(inst.TLS, &http.Client{
+		Transport:     t,
+		CheckRedirect: p.client.CheckRedirect,
+	})
+	return c.(*http.Client)
+}
+
+// ParseStatusRange 解析状态码区间，支持 "200" 与 "200-399"
+func ParseStatusRange(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	lo, hi, found := strings.Cut(s, "-")
+	min, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status range %q", s)
+	}
+	if !found {
+		return min, min, nil
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(hi))
+	if err != nil || max < min {
+		return 0, 0, fmt.Errorf("invalid status range %q", s)
+	}
+	return min, max, nil
+}
@@ -0,0 +1,115 @@
+package lb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	cases := []struct {
+		in       string
+		min, max int
+		err      bool
+	}{
+		{"", 0, 0, false},
+		{"200", 200, 200, false},
+		{"200-399", 200, 399, false},
+		{" 200 - 204 ", 200, 204, false},
+		{"abc", 0, 0, true},
+		{"200-", 0, 0, true},
+		{"399-200", 0, 0, true},
+	}
+	for _, tc := range cases {
+		min, max, err := ParseStatusRange(tc.in)
+		if (err != nil) != tc.err || min != tc.min || max != tc.max {
+			t.Fatalf("ParseStatusRange(%q) = %d, %d, %v", tc.in, min, max, err)
+		}
+	}
+}
+
+// probeHandler /status/<code> 返回对应状态码，其余路径返回请求的 Host
+func probeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/status/204":
+		w.WriteHeader(http.StatusNoContent)
+	case "/status/302":
+		http.Redirect(w, r, "/", http.StatusFound)
+	case "/status/503":
+		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		_, _ = w.Write([]byte(`{"status": "UP", "host": "` + r.Host + `"}`))
+	}
+}
+
+func TestHTTPProber(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(probeHandler))
+	defer s.Close()
+	inst := Instance{Addr: s.Listener.Addr().String()}
+	cases := []struct {
+		name  string
+		check HTTPCheck
+		ok    bool
+	}{
+		{"default range", HTTPCheck{Path: "/status/204"}, true},
+		{"redirect not followed", HTTPCheck{Path: "/status/302"}, true},
+		{"unhealthy status", HTTPCheck{Path: "/status/503"}, false},
+		{"exact status", HTTPCheck{Path: "/status/302", StatusMin: 200, StatusMax: 200}, false},
+		{"custom range", HTTPCheck{Path: "/status/503", StatusMin: 500, StatusMax: 503}, true},
+		{"body contains", HTTPCheck{BodyContains: `"UP"`}, true},
+		{"body not contains", HTTPCheck{BodyContains: "DOWN"}, false},
+		{"body regex", HTTPCheck{BodyRegex: regexp.MustCompile(`"status":\s*"UP"`)}, true},
+		{"body regex mismatch", HTTPCheck{BodyRegex: regexp.MustCompile(`"status":\s*"DOWN"`)}, false},
+		{"host override", HTTPCheck{Headers: map[string]string{"host": "api.internal"}, BodyContains: `"host": "api.internal"`}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewHTTPProber(tc.check).Probe(context.Background(), inst)
+			if (err == nil) != tc.ok {
+				t.Fatalf("probe err = %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestHTTPProberTLSReusesConnection(t *testing.T) {
+	var conns atomic.Int32
+	s := httptest.NewUnstartedServer(http.HandlerFunc(probeHandler))
+	s.Config.ConnState = func(_ net.Conn, st http.ConnState) {
+		if st == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	s.StartTLS()
+	defer s.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	inst := Instance{Addr: s.Listener.Addr().String(), Protocol: "https", TLS: &tls.Config{RootCAs: pool}}
+
+	p := NewHTTPProber(HTTPCheck{})
+	if err := p.Probe(context.Background(), Instance{Addr: inst.Addr, Protocol: "https"}); err == nil ||
+		!strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("probe without instance TLS err = %v, want certificate error", err)
+	}
+	conns.Store(0)
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := p.Probe(ctx, inst)
+		cancel()
+		if err != nil {
+			t.Fatalf("probe %d: %v", i, err)
+		}
+	}
+	// 未读的响应体在关闭前丢弃，连续探测复用同一 TLS 连接
+	if n := conns.Load(); n != 1 {
+		t.Fatalf("new connections = %d, want 1", n)
+	}
+}
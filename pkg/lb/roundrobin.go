@@ -1,53 +1,83 @@
 package lb
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// peer 平滑加权轮询节点
+type peer struct {
+	inst    Instance
+	current int // 当前权重
+}
+
+// rrSnapshot 一次 Update 生成的节点快照，Pick 只在快照内加锁
+type rrSnapshot struct {
+	mu    sync.Mutex
+	peers []*peer
+	total int // 权重总和
+}
+
+// weightedRR nginx 平滑加权轮询：每次所有节点 current += weight，
+// 选 current 最大者并减去总权重，高权重节点的请求被均匀打散
 type weightedRR struct {
-	insts []Instance // 实例列表
-	total int        // 权重总和
-	pos   uint64     // 原子游标
+	snap atomic.Pointer[rrSnapshot]
 }
 
 func New(insts []Instance) Balancer {
-	w := &weightedRR{insts: insts}
-	for _, v := range insts {
-		w.total += v.Weight
-	}
+	w := &weightedRR{}
+	w.Update(insts)
 	return w
 }
 
-func (w *weightedRR) Pick() (string, bool, bool) {
-	if w.total == 0 {
-		return "", false, false
+func (w *weightedRR) Pick(info PickInfo) (Instance, bool) {
+	s := w.snap.Load()
+	if s.total == 0 {
+		return Instance{}, false
 	}
-	pos := atomic.AddUint64(&w.pos, 1) % uint64(w.total)
-	for _, v := range w.insts {
-		if pos < uint64(v.Weight) {
-			return getAddr(v), true, v.IsRemovePrex
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best *peer
+	total := 0
+	for _, p := range s.peers {
+		// 被排除的节点不参与本轮加权，与 nginx 的 tried 处理一致
+		if info.Exclude[p.inst.Addr] {
+			continue
+		}
+		p.current += p.inst.Weight
+		total += p.inst.Weight
+		if best == nil || p.current > best.current {
+			best = p
 		}
-		pos -= uint64(v.Weight)
 	}
-	if len(w.insts) == 0 {
-		return "", false, false
+	if best == nil {
+		return Instance{}, false
 	}
-	// 兜底
-	defaultInstance := w.insts[0]
-	return getAddr(defaultInstance), true, defaultInstance.IsRemovePrex
+	best.current -= total
+	return best.inst, true
 }
 
-func getAddr(inst Instance) string {
-	if inst.Protocol == "" {
-		inst.Protocol = "http"
-	}
-	return inst.Protocol + "://" + inst.Addr
-}
+// Done 轮询不关心请求结果
+func (w *weightedRR) Done(Instance, time.Duration, error) {}
 
+// Update 生成新快照并原子替换，保留仍存在节点的当前权重
 func (w *weightedRR) Update(insts []Instance) {
-	w.insts = insts
-	w.total = 0
+	prev := make(map[string]int)
+	if old := w.snap.Load(); old != nil {
+		old.mu.Lock()
+		for _, p := range old.peers {
+			prev[p.inst.Addr] = p.current
+		}
+		old.mu.Unlock()
+	}
+	s := &rrSnapshot{}
 	for _, v := range insts {
-		w.total += v.Weight
+		if v.Weight <= 0 {
+			continue
+		}
+		s.peers = append(s.peers, &peer{inst: v, current: prev[v.Addr]})
+		s.total += v.Weight
 	}
+	w.snap.Store(s)
 }
@@ -0,0 +1,83 @@
+package lb
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSmoothWeightedRR(t *testing.T) {
+	b := New([]Instance{
+		{Addr: "a", Weight: 5},
+		{Addr: "b", Weight: 1},
+		{Addr: "c", Weight: 1},
+	})
+	var seq []string
+	for i := 0; i < 7; i++ {
+		inst, ok := b.Pick(PickInfo{})
+		if !ok {
+			t.Fatal("pick failed")
+		}
+		seq = append(seq, inst.Addr)
+	}
+	if got, want := strings.Join(seq, ","), "a,a,b,a,c,a,a"; got != want {
+		t.Fatalf("sequence = %s, want %s", got, want)
+	}
+}
+
+func TestWeightedRRDistribution(t *testing.T) {
+	b := New([]Instance{
+		{Addr: "a", Weight: 3},
+		{Addr: "b", Weight: 2},
+		{Addr: "c", Weight: 0},
+	})
+	count := make(map[string]int)
+	for i := 0; i < 500; i++ {
+		inst, _ := b.Pick(PickInfo{})
+		count[inst.Addr]++
+	}
+	if count["a"] != 300 || count["b"] != 200 || count["c"] != 0 {
+		t.Fatalf("distribution = %v", count)
+	}
+}
+
+func TestWeightedRREmpty(t *testing.T) {
+	b := New(nil)
+	if _, ok := b.Pick(PickInfo{}); ok {
+		t.Fatal("pick from empty balancer should fail")
+	}
+	b.Update([]Instance{{Addr: "a", Weight: 1}})
+	if inst, ok := b.Pick(PickInfo{}); !ok || inst.URL() != "http://a" {
+		t.Fatalf("pick = %s %v", inst.URL(), ok)
+	}
+}
+
+func TestWeightedRRConcurrentPickUpdate(t *testing.T) {
+	pool := []Instance{
+		{Addr: "a", Weight: 3},
+		{Addr: "b", Weight: 2},
+		{Addr: "c", Weight: 1},
+	}
+	b := New(pool)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					b.Pick(PickInfo{})
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		b.Update(pool[:1+i%len(pool)])
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package lb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLeastConnAvoidsBusyInstance(t *testing.T) {
+	b := NewLeastConn([]Instance{{Addr: "a", Weight: 1}, {Addr: "b", Weight: 1}})
+	first, _ := b.Pick(PickInfo{})
+	// 第一个请求未结束时，后续请求都应落到另一个节点
+	for i := 0; i < 10; i++ {
+		inst, ok := b.Pick(PickInfo{})
+		if !ok || inst.Addr == first.Addr {
+			t.Fatalf("pick = %s, want the idle instance", inst.Addr)
+		}
+		b.Done(inst, time.Millisecond, nil)
+	}
+	b.Done(first, time.Millisecond, nil)
+}
+
+func TestP2CEWMAPrefersFastInstance(t *testing.T) {
+	b := NewP2CEWMA([]Instance{{Addr: "fast", Weight: 1}, {Addr: "slow", Weight: 1}})
+	for _, inst := range []Instance{{Addr: "fast", Weight: 1}, {Addr: "slow", Weight: 1}} {
+		b.(*p2cEWMA).pool.byAddr[inst.Addr].inflight.Add(1)
+	}
+	b.Done(Instance{Addr: "fast"}, time.Millisecond, nil)
+	b.Done(Instance{Addr: "slow"}, 500*time.Millisecond, nil)
+	count := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		inst, _ := b.Pick(PickInfo{})
+		count[inst.Addr]++
+		b.Done(inst, 0, ErrSkipped)
+	}
+	if count["fast"] != 100 {
+		t.Fatalf("distribution = %v, want all on fast", count)
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	for _, s := range []string{"", StrategyRoundRobin, StrategyLeastConn, StrategyP2CEWMA} {
+		if _, err := NewStrategy(s, nil); err != nil {
+			t.Fatalf("strategy %q: %v", s, err)
+		}
+	}
+	if _, err := NewStrategy("random", nil); err == nil {
+		t.Fatal("unknown strategy should fail")
+	}
+}
+
+func TestPickExclude(t *testing.T) {
+	pool := []Instance{{Addr: "a", Weight: 1}, {Addr: "b", Weight: 2}, {Addr: "c", Weight: 1}}
+	for _, s := range []string{StrategyRoundRobin, StrategyLeastConn, StrategyP2CEWMA, StrategyHash} {
+		b, _ := NewStrategy(s, pool)
+		for _, key := range []string{"", "user-1"} {
+			for i := 0; i < 20; i++ {
+				inst, ok := b.Pick(PickInfo{HashKey: key, Exclude: map[string]bool{"a": true, "b": true}})
+				if !ok || inst.Addr != "c" {
+					t.Fatalf("%s: pick = %s, want c", s, inst.Addr)
+				}
+				b.Done(inst, 0, ErrSkipped)
+			}
+		}
+		if inst, ok := b.Pick(PickInfo{Exclude: map[string]bool{"a": true, "b": true, "c": true}}); ok {
+			t.Fatalf("%s: pick = %s, want none when all excluded", s, inst.Addr)
+		}
+	}
+}
+
+func TestNodePoolConcurrentPickUpdate(t *testing.T) {
+	pool := []Instance{{Addr: "a", Weight: 3}, {Addr: "b", Weight: 2}, {Addr: "c", Weight: 1}}
+	for _, s := range []string{StrategyLeastConn, StrategyP2CEWMA} {
+		b, _ := NewStrategy(s, pool)
+		var wg, started sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			started.Add(1)
+			go func() {
+				defer wg.Done()
+				started.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						if inst, ok := b.Pick(PickInfo{}); ok {
+							b.Done(inst, time.Millisecond, nil)
+						}
+					}
+				}
+			}()
+		}
+		started.Wait()
+		for i := 0; i < 1000; i++ {
+			// 权重变化同样替换节点
+			insts := append([]Instance(nil), pool[:1+i%len(pool)]...)
+			insts[0].Weight = 1 + i%5
+			b.Update(insts)
+		}
+		close(stop)
+		wg.Wait()
+	}
+}
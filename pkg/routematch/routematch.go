@@ -0,0 +1,149 @@
+package routematch
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// Matcher 路由匹配器，路由网关与鉴权中间件共用，保证两者命中同一条路由。
+// 构建后只读，可并发使用
+type Matcher[T any] struct {
+	entries []entry[T]
+}
+
+type entry[T any] struct {
+	path     string          // 路由前缀
+	priority int             // 显式优先级
+	hosts    []string        // 小写的 Host 匹配规则，为空匹配任意 Host
+	methods  map[string]bool // 允许的请求方法，为空允许全部
+	allow    []string        // 配置的方法，用于 405 的 Allow
+	value    T
+}
+
+// Result 匹配结果，未命中且存在路径命中但方法不允许的路由时 Allow 为这些路由允许的方法
+type Result[T any] struct {
+	Value T
+	Rest  string // 路由前缀之后的路径
+	Allow []string
+}
+
+// score 匹配程度，依次比较优先级、Host 精确程度、路径长度
+type score struct {
+	priority int
+	host     int
+	path     int
+}
+
+func (s score) less(o score) bool {
+	if s.priority != o.priority {
+		return s.priority < o.priority
+	}
+	if s.host != o.host {
+		return s.host < o.host
+	}
+	return s.path < o.path
+}
+
+func New[T any]() *Matcher[T] {
+	return &Matcher[T]{}
+}
+
+// Add 追加路由，匹配程度相同时先添加的优先
+func (m *Matcher[T]) Add(rule config.RoutesConfig, v T) {
+	e := entry[T]{path: rule.Path, priority: rule.Priority, allow: rule.Methods, value: v}
+	for _, h := range rule.Hosts {
+		e.hosts = append(e.hosts, strings.ToLower(strings.TrimSuffix(h, ".")))
+	}
+	if len(rule.Methods) > 0 {
+		e.methods = make(map[string]bool, len(rule.Methods))
+		for _, method := range rule.Methods {
+			e.methods[strings.ToUpper(method)] = true
+		}
+	}
+	m.entries = append(m.entries, e)
+}
+
+// Match 按路径段匹配前缀，/dm 命中 /dm、/dm/x，不命中 /dmx。
+// 多条路由命中时优先级高者优先，其次 Host 越精确越优先，最后取最长路径；
+// 最优的路由都不允许该方法时返回 ok=false 及 Allow，用于 405
+func (m *Matcher[T]) Match(host, method, path string) (Result[T], bool) {
+	var (
+		res   Result[T]
+		found bool
+		best  = score{priority: math.MinInt, host: -1, path: -1}
+	)
+	for i := range m.entries {
+		e := &m.entries[i]
+		hs := e.hostScore(host)
+		if hs < 0 {
+			continue
+		}
+		rest, ok := matchPath(e.path, path)
+		if !ok {
+			continue
+		}
+		s := score{priority: e.priority, host: hs, path: len(strings.TrimSuffix(e.path, "/"))}
+		if s.less(best) {
+			continue
+		}
+		if best.less(s) {
+			best, found = s, false
+			res = Result[T]{}
+		}
+		if found {
+			continue
+		}
+		if e.methods != nil && !e.methods[strings.ToUpper(method)] {
+			res.Allow = append(res.Allow, e.allow...)
+			continue
+		}
+		res, found = Result[T]{Value: e.value, Rest: rest}, true
+	}
+	return res, found
+}
+
+// hostScore Host 匹配程度：精确匹配最高，通配符按后缀长度，未配置 Host 为 0，不匹配为 -1
+func (e *entry[T]) hostScore(host string) int {
+	if len(e.hosts) == 0 {
+		return 0
+	}
+	score := -1
+	for _, h := range e.hosts {
+		switch {
+		case h == host:
+			return math.MaxInt32
+		case strings.HasPrefix(h, "*.") && len(host) > len(h)-1 && strings.HasSuffix(host, h[1:]):
+			score = max(score, len(h)-1)
+		}
+	}
+	return score
+}
+
+// matchPath 按路径段匹配前缀，返回前缀之后的部分
+func matchPath(p, path string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+	prefix := strings.TrimSuffix(p, "/")
+	switch {
+	case path == p || path == prefix:
+		return "", true
+	case strings.HasPrefix(path, prefix+"/"):
+		return path[len(prefix):], true
+	default:
+		return "", false
+	}
+}
+
+// Host 去掉端口的小写 Host
+func Host(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
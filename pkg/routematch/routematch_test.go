@@ -0,0 +1,75 @@
+package routematch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+func TestMatch(t *testing.T) {
+	routes := []config.RoutesConfig{
+		{Name: "root", Path: "/"},
+		{Name: "dm", Path: "/dm"},
+		{Name: "dm-api", Path: "/dm/api/"},
+		{Name: "dm-api-dup", Path: "/dm/api"},
+		{Name: "chain", Path: "/chain", Hosts: []string{"*.example.com"}},
+		{Name: "chain-exact", Path: "/chain", Hosts: []string{"api.example.com"}},
+		{Name: "chain-any", Path: "/chain/v1"},
+		{Name: "rpc-get", Path: "/rpc", Methods: []string{"GET"}},
+		{Name: "rpc-post", Path: "/rpc", Methods: []string{"post"}},
+		{Name: "write", Path: "/write", Methods: []string{"POST", "PUT"}},
+		{Name: "write-low", Path: "/write/all"},
+		{Name: "pinned", Path: "/pin", Priority: 10},
+		{Name: "pin-long", Path: "/pin/long/path"},
+	}
+	m := New[string]()
+	for _, r := range routes {
+		m.Add(r, r.Name)
+	}
+	cases := []struct {
+		name   string
+		host   string
+		method string
+		path   string
+		want   string
+		rest   string
+		allow  []string
+	}{
+		{"exact", "", "GET", "/dm", "dm", "", nil},
+		{"sub path", "", "GET", "/dm/x", "dm", "/x", nil},
+		{"segment boundary", "", "GET", "/dmx/y", "root", "/dmx/y", nil},
+		{"longest prefix", "", "GET", "/dm/api/v1", "dm-api", "/v1", nil},
+		{"trailing slash tie keeps first", "", "GET", "/dm/api", "dm-api", "", nil},
+		{"root", "", "GET", "/other", "root", "/other", nil},
+		{"wildcard host", "a.example.com", "GET", "/chain/x", "chain", "/x", nil},
+		{"exact host", "api.example.com", "GET", "/chain/x", "chain-exact", "/x", nil},
+		{"host beats longer path", "api.example.com", "GET", "/chain/v1/x", "chain-exact", "/v1/x", nil},
+		{"host mismatch", "other.com", "GET", "/chain/v1/x", "chain-any", "/x", nil},
+		{"method get", "", "GET", "/rpc", "rpc-get", "", nil},
+		{"method case", "", "POST", "/rpc/a", "rpc-post", "/a", nil},
+		{"method not allowed", "", "DELETE", "/write/x", "", "", []string{"POST", "PUT"}},
+		{"longer path allows method", "", "DELETE", "/write/all", "write-low", "", nil},
+		{"priority beats longest prefix", "", "GET", "/pin/long/path/x", "pinned", "/long/path/x", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, ok := m.Match(tc.host, tc.method, tc.path)
+			if ok != (tc.want != "") || res.Value != tc.want || res.Rest != tc.rest || !reflect.DeepEqual(res.Allow, tc.allow) {
+				t.Fatalf("Match(%q, %q, %q) = %+v, %v, want %q rest %q allow %v",
+					tc.host, tc.method, tc.path, res, ok, tc.want, tc.rest, tc.allow)
+			}
+		})
+	}
+}
+
+func TestMatchNotFound(t *testing.T) {
+	m := New[string]()
+	m.Add(config.RoutesConfig{Path: "/dm"}, "dm")
+	m.Add(config.RoutesConfig{Path: "/api", Hosts: []string{"api.example.com"}}, "api")
+	for _, path := range []string{"/", "/d", "/dmx", "/api/x"} {
+		if res, ok := m.Match("other.com", "GET", path); ok || len(res.Allow) > 0 {
+			t.Fatalf("Match(%q) = %+v, want not found", path, res)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/wswlog/wlogging"
+)
+
+var logger = wlogging.MustGetFileLoggerWithoutName(nil)
+
+// reloadDelay 文件变化后延迟加载，合并证书与私钥先后写入产生的多次事件
+const reloadDelay = 500 * time.Millisecond
+
+// ParseClientAuth 解析客户端证书校验方式
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch s {
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "", "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unsupported client auth %q", s)
+	}
+}
+
+// serverState 一次加载得到的证书与客户端 CA
+type serverState struct {
+	certs     []*tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Server 网关监听使用的 TLS 配置，证书按 SNI 选择，文件变化时热加载
+type Server struct {
+	cfg        config.ServerTLSConfig
+	minVersion uint16
+	clientAuth tls.ClientAuthType
+	state      atomic.Pointer[serverState]
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	timer   *time.Timer
+}
+
+// NewServer 加载证书，首次加载失败时返回错误
+func NewServer(cfg config.ServerTLSConfig) (*Server, error) {
+	if len(cfg.Certs) == 0 {
+		return nil, errors.New("no server certificate configured")
+	}
+	s := &Server{cfg: cfg, minVersion: tls.VersionTLS12, clientAuth: tls.NoClientCert}
+	if cfg.MinVersion != "" {
+		v, err := ParseVersion(cfg.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		s.minVersion = v
+	}
+	if cfg.ClientCAFile != "" || cfg.ClientAuth != "" {
+		auth, err := ParseClientAuth(cfg.ClientAuth)
+		if err != nil {
+			return nil, err
+		}
+		s.clientAuth = auth
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) load() error {
+	st := &serverState{}
+	for _, c := range s.cfg.Certs {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", c.CertFile, err)
+		}
+		st.certs = append(st.certs, &cert)
+	}
+	if s.cfg.ClientCAFile != "" {
+		pool, err := LoadCertPool(s.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		st.clientCAs = pool
+	}
+	s.state.Store(st)
+	return nil
+}
+
+// Config 返回监听使用的 tls.Config，每次握手读取最新证书
+func (s *Server) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: s.minVersion,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			st := s.state.Load()
+			return &tls.Config{
+				MinVersion:   s.minVersion,
+				ClientAuth:   s.clientAuth,
+				ClientCAs:    st.clientCAs,
+				Certificates: []tls.Certificate{*pickCert(st.certs, hello)},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// pickCert 选择支持该 ClientHello（SNI、签名算法）的第一张证书，都不匹配时使用第一张
+func pickCert(certs []*tls.Certificate, hello *tls.ClientHelloInfo) *tls.Certificate {
+	if len(certs) > 1 {
+		for _, cert := range certs {
+			if hello.SupportsCertificate(cert) == nil {
+				return cert
+			}
+		}
+	}
+	return certs[0]
+}
+
+// Watch 监听证书所在目录，文件变化（包括 k8s secret 的符号链接切换）后重新加载，
+// 加载失败时保留旧证书
+func (s *Server) Watch() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := make(map[string]bool)
+	for _, c := range s.cfg.Certs {
+		dirs[filepath.Dir(c.CertFile)] = true
+		dirs[filepath.Dir(c.KeyFile)] = true
+	}
+	if s.cfg.ClientCAFile != "" {
+		dirs[filepath.Dir(s.cfg.ClientCAFile)] = true
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.watcher = w
+	s.mu.Unlock()
+	go s.watch(w)
+	return nil
+}
+
+func (s *Server) watch(w *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			s.mu.Lock()
+			if s.timer != nil {
+				s.timer.Stop()
+			}
+			s.timer = time.AfterFunc(reloadDelay, s.reload)
+			s.mu.Unlock()
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			logger.Errorf("watch tls certificates: %v", err)
+		}
+	}
+}
+
+func (s *Server) reload() {
+	if err := s.load(); err != nil {
+		logger.Errorf("reload tls certificates failed, keep previous: %v", err)
+		return
+	}
+	logger.Info("tls certificates reloaded")
+}
+
+// Close 停止监听证书文件
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if s.watcher == nil {
+		return nil
+	}
+	return s.watcher.Close()
+}
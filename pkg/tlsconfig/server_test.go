@@ -0,0 +1,114 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// writeCert 生成自签名证书写入 dir/name.crt 与 dir/name.key
+func writeCert(t *testing.T, dir, name, host string, serial int64) config.CertConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config.CertConfig{CertFile: filepath.Join(dir, name+".crt"), KeyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(c.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// serverCert 以 serverName 握手，返回服务端证书
+func serverCert(t *testing.T, conf *tls.Config, serverName string) *x509.Certificate {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+func TestServerSNIAndReload(t *testing.T) {
+	dir := t.TempDir()
+	a := writeCert(t, dir, "a", "a.example.com", 1)
+	b := writeCert(t, dir, "b", "b.example.com", 2)
+	s, err := NewServer(config.ServerTLSConfig{Certs: []config.CertConfig{a, b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	conf := s.Config()
+
+	if cert := serverCert(t, conf, "b.example.com"); cert.Subject.CommonName != "b.example.com" {
+		t.Fatalf("sni b got %s", cert.Subject.CommonName)
+	}
+	// 无匹配时使用第一张
+	if cert := serverCert(t, conf, "other.example.com"); cert.Subject.CommonName != "a.example.com" {
+		t.Fatalf("default got %s", cert.Subject.CommonName)
+	}
+
+	if err := s.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	writeCert(t, dir, "b", "b.example.com", 3)
+	deadline := time.Now().Add(5 * time.Second)
+	for serverCert(t, conf, "b.example.com").SerialNumber.Int64() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not reloaded")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// 写入无效证书时保留旧证书
+	if err := os.WriteFile(b.CertFile, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * reloadDelay)
+	if cert := serverCert(t, conf, "b.example.com"); cert.SerialNumber.Int64() != 3 {
+		t.Fatalf("serial = %d after broken reload, want 3", cert.SerialNumber.Int64())
+	}
+}
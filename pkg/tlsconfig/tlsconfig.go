@@ -0,0 +1,69 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// ParseVersion 解析 TLS 版本，支持 1.0 ~ 1.3，为空时返回 0 使用 Go 默认值
+func ParseVersion(s string) (uint16, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version %q", s)
+	}
+}
+
+// LoadCertPool 读取 PEM 格式的 CA 证书
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", file)
+	}
+	return pool, nil
+}
+
+// Client 按上游配置创建 TLS 客户端配置，配置为空时返回 nil 使用系统默认
+func Client(cfg config.UpstreamTLSConfig) (*tls.Config, error) {
+	if cfg == (config.UpstreamTLSConfig{}) {
+		return nil, nil
+	}
+	conf := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	var err error
+	if conf.MinVersion, err = ParseVersion(cfg.MinVersion); err != nil {
+		return nil, err
+	}
+	if cfg.CAFile != "" {
+		if conf.RootCAs, err = LoadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// sendChunked 以分块编码发送请求体，网关无法从 Content-Length 得知长度
+func sendChunked(t *testing.T, url, body string) int {
+	req, _ := http.NewRequest(http.MethodPost, url, io.MultiReader(strings.NewReader(body)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestLimitBody(t *testing.T) {
+	var hits atomic.Int32
+	up := echoUpstream(t, &hits)
+	plain := newTestGateway(t, Options{Balancer: lb.New([]lb.Instance{up}), MaxBodyBytes: 8})
+	retry := newTestGateway(t, Options{
+		Balancer:     lb.New([]lb.Instance{up}),
+		Retry:        NewRetryPolicy(config.RetryConfig{Attempts: 2, NonIdempotent: true, Backoff: time.Millisecond}),
+		MaxBodyBytes: 8,
+	})
+	for _, gw := range []string{plain, retry} {
+		if code, got := send(t, http.MethodPost, gw+"/api/x", "12345678"); code != http.StatusOK || got != "12345678" {
+			t.Fatalf("body at limit = %d %q, want 200", code, got)
+		}
+		// Content-Length 超限时不转发，直接返回 413
+		hits.Store(0)
+		if code, _ := send(t, http.MethodPost, gw+"/api/x", "123456789"); code != http.StatusRequestEntityTooLarge || hits.Load() != 0 {
+			t.Fatalf("content-length over limit = %d with %d upstream hits, want 413 without forwarding", code, hits.Load())
+		}
+		// 分块请求体读到超限时中断转发，返回 413
+		if code := sendChunked(t, gw+"/api/x", strings.Repeat("x", 1024)); code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("chunked over limit = %d, want 413", code)
+		}
+		if code := sendChunked(t, gw+"/api/x", "1234"); code != http.StatusOK {
+			t.Fatalf("chunked within limit = %d, want 200", code)
+		}
+	}
+}
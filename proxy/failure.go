@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+var (
+	ErrUpstreamConnect  = errors.New("upstream connect failed")
+	ErrUpstreamTimeout  = errors.New("upstream timeout")
+	ErrUpstreamCanceled = errors.New("client canceled")
+	ErrUpstreamStatus   = errors.New("upstream failure status")
+	ErrBodyTooLarge     = errors.New("request body too large")
+)
+
+// FailurePolicy 判定一次转发是否计为熔断失败
+type FailurePolicy struct {
+	statusCodes   map[int]bool // 计为失败的状态码，为空时 5xx 均为失败
+	connectErrors bool         // 连接失败计为失败
+	timeoutErrors bool         // 超时计为失败
+}
+
+func NewFailurePolicy(cfg config.FailureConfig) FailurePolicy {
+	p := FailurePolicy{connectErrors: true, timeoutErrors: true}
+	if cfg.ConnectErrors != nil {
+		p.connectErrors = *cfg.ConnectErrors
+	}
+	if cfg.TimeoutErrors != nil {
+		p.timeoutErrors = *cfg.TimeoutErrors
+	}
+	if len(cfg.StatusCodes) > 0 {
+		p.statusCodes = make(map[int]bool, len(cfg.StatusCodes))
+		for _, code := range cfg.StatusCodes {
+			p.statusCodes[code] = true
+		}
+	}
+	return p
+}
+
+// Classify 返回计入熔断的错误，nil 表示本次成功；err 为已归类的上游错误
+func (p FailurePolicy) Classify(status int, err error) error {
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUpstreamTimeout):
+			if p.timeoutErrors {
+				return err
+			}
+		case errors.Is(err, ErrUpstreamConnect):
+			if p.connectErrors {
+				return err
+			}
+		}
+		// 客户端主动断开等不计为上游失败
+		return nil
+	}
+	if p.statusCodes == nil {
+		if status >= http.StatusInternalServerError {
+			return fmt.Errorf("%w: %d", ErrUpstreamStatus, status)
+		}
+		return nil
+	}
+	if p.statusCodes[status] {
+		return fmt.Errorf("%w: %d", ErrUpstreamStatus, status)
+	}
+	return nil
+}
+
+// upstreamError 将 ReverseProxy 的错误归类为连接失败、超时、客户端取消或请求体超限
+func upstreamError(err error) error {
+	var (
+		netErr     net.Error
+		maxBodyErr *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &maxBodyErr):
+		return fmt.Errorf("%w: %v", ErrBodyTooLarge, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%w: %v", ErrUpstreamCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+	default:
+		return fmt.Errorf("%w: %v", ErrUpstreamConnect, err)
+	}
+}
+
+// errorStatus 上游错误对应的响应码
+func errorStatus(err error) int {
+	if errors.Is(err, ErrUpstreamTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, ErrBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadGateway
+}
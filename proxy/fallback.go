@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/auth"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	FallbackStatic = "static" // 静态响应
+	FallbackRoute  = "route"  // 转发到备用路由
+	FallbackCache  = "cache"  // 返回最近一次成功的 GET 响应
+
+	// FallbackHeader 标记响应来自降级
+	FallbackHeader = "X-Gateway-Fallback"
+
+	defaultCacheTTL      = 5 * time.Minute
+	defaultCacheMaxBytes = 1 << 20
+)
+
+// Fallback 熔断或无可用实例时的降级处理
+type Fallback interface {
+	Serve(c *gin.Context) bool // 返回 false 表示无法降级，由调用方返回原错误
+}
+
+// recorder 需要记录正常响应的降级实现
+type recorder interface {
+	record(c *gin.Context) (done func())
+}
+
+// NewFallback 创建 static / cache 降级，route 降级依赖路由表由 router 创建
+func NewFallback(cfg config.FallbackConfig) Fallback {
+	switch cfg.Type {
+	case FallbackStatic:
+		return newStaticFallback(cfg)
+	case FallbackCache:
+		return newCacheFallback(cfg)
+	default:
+		return nil
+	}
+}
+
+// degrade 执行降级，未配置或降级失败时返回原错误
+func degrade(c *gin.Context, fb Fallback, msg string) {
+	if fb != nil && fb.Serve(c) {
+		return
+	}
+	auth.ResultCode(c, http.StatusServiceUnavailable, msg)
+}
+
+/* ---------- static ---------- */
+
+type staticFallback struct {
+	status      int
+	headers     map[string]string
+	contentType string
+	body        []byte
+}
+
+func newStaticFallback(cfg config.FallbackConfig) *staticFallback {
+	f := &staticFallback{
+		status:      cfg.Status,
+		headers:     cfg.Headers,
+		contentType: cfg.ContentType,
+		body:        []byte(cfg.Body),
+	}
+	// 默认 503，降级响应不应被客户端与监控当作成功
+	if f.status == 0 {
+		f.status = http.StatusServiceUnavailable
+	}
+	if f.contentType == "" {
+		f.contentType = "application/json; charset=utf-8"
+	}
+	return f
+}
+
+func (f *staticFallback) Serve(c *gin.Context) bool {
+	for k, v := range f.headers {
+		c.Header(k, v)
+	}
+	c.Header(FallbackHeader, FallbackStatic)
+	c.Data(f.status, f.contentType, f.body)
+	return true
+}
+
+/* ---------- cache ---------- */
+
+// cachedResponse 缓存的成功响应
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+type cacheFallback struct {
+	c        *cache.Cache
+	maxBytes int
+}
+
+func newCacheFallback(cfg config.FallbackConfig) *cacheFallback {
+	ttl := cfg.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	maxBytes := cfg.CacheMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultCacheMaxBytes
+	}
+	return &cacheFallback{c: cache.New(ttl, 2*ttl), maxBytes: maxBytes}
+}
+
+func (f *cacheFallback) Serve(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet {
+		return false
+	}
+	vary, ok := f.c.Get(varyPrefix + c.Request.RequestURI)
+	if !ok {
+		return false
+	}
+	v, ok := f.c.Get(cacheKey(c.Request, vary.([]string)))
+	if !ok {
+		return false
+	}
+	resp := v.(*cachedResponse)
+	for k, vs := range resp.header {
+		for _, hv := range vs {
+			c.Writer.Header().Add(k, hv)
+		}
+	}
+	c.Header(FallbackHeader, FallbackCache)
+	c.Status(resp.status)
+	_, _ = c.Writer.Write(resp.body)
+	return true
+}
+
+// record 转发前包装 Writer，转发完成后缓存 2xx 的 GET 响应。
+// 携带 Authorization 或 Cookie 的请求可能属于特定用户，不缓存
+func (f *cacheFallback) record(c *gin.Context) func() {
+	if c.Request.Method != http.MethodGet || c.Request.Header.Get("Authorization") != "" || c.Request.Header.Get("Cookie") != "" {
+		return func() {}
+	}
+	origin := c.Writer
+	w := &captureWriter{ResponseWriter: origin, limit: f.maxBytes}
+	c.Writer = w
+	return func() {
+		c.Writer = origin
+		status := w.Status()
+		if !w.Written() || w.overflow || status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+		header := w.Header().Clone()
+		// 不缓存带会话信息或上游声明不可共享缓存的响应
+		if header.Get("Set-Cookie") != "" || !sharedCacheable(header) {
+			return
+		}
+		vary := varyHeaders(header)
+		if slices.Contains(vary, "*") {
+			return
+		}
+		header.Del(FallbackHeader)
+		f.c.SetDefault(varyPrefix+c.Request.RequestURI, vary)
+		f.c.SetDefault(cacheKey(c.Request, vary), &cachedResponse{status: status, header: header, body: w.buf.Bytes()})
+	}
+}
+
+// varyPrefix 按 URI 记录响应 Vary 请求头的缓存 key 前缀，RequestURI 以 / 开头不会冲突
+const varyPrefix = "vary:"
+
+// cacheKey URI 加上 Vary 列出的请求头取值，Vary 不同的请求互不命中
+func cacheKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(r.RequestURI)
+	for _, h := range vary {
+		b.WriteString("\n")
+		b.WriteString(h)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// varyHeaders 响应 Vary 中的请求头名，已规范化
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// sharedCacheable Cache-Control 含 private 或 no-store 时不可被网关缓存
+func sharedCacheable(header http.Header) bool {
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if d == "no-store" || d == "private" || strings.HasPrefix(d, "private=") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// captureWriter 透传响应的同时保留一份响应体
+type captureWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) capture(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+len(b) > w.limit {
+		w.overflow = true
+		w.buf = bytes.Buffer{}
+		return
+	}
+	w.buf.Write(b)
+}
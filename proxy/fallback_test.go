@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+func newGetContext(header http.Header) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/dm/items?page=1", nil)
+	for k, vs := range header {
+		c.Request.Header[k] = vs
+	}
+	return c, w
+}
+
+// recordResponse 模拟一次经 cache 降级记录的上游响应
+func recordResponse(f *cacheFallback, reqHeader, respHeader http.Header, body string) {
+	c, _ := newGetContext(reqHeader)
+	done := f.record(c)
+	for k, vs := range respHeader {
+		c.Writer.Header()[k] = vs
+	}
+	c.String(http.StatusOK, body)
+	done()
+}
+
+func serveCached(f *cacheFallback, reqHeader http.Header) (string, bool) {
+	c, w := newGetContext(reqHeader)
+	if !f.Serve(c) {
+		return "", false
+	}
+	return w.Body.String(), true
+}
+
+func TestCacheFallback(t *testing.T) {
+	f := newCacheFallback(config.FallbackConfig{})
+	recordResponse(f, nil, nil, "public")
+	if body, ok := serveCached(f, nil); !ok || body != "public" {
+		t.Fatalf("serve = %q, %v, want cached public response", body, ok)
+	}
+
+	// Vary 不同的请求不命中
+	f = newCacheFallback(config.FallbackConfig{})
+	recordResponse(f, http.Header{"Accept-Language": {"zh"}}, http.Header{"Vary": {"accept-language"}}, "zh")
+	if body, ok := serveCached(f, http.Header{"Accept-Language": {"zh"}}); !ok || body != "zh" {
+		t.Fatalf("serve = %q, %v, want zh", body, ok)
+	}
+	if body, ok := serveCached(f, http.Header{"Accept-Language": {"en"}}); ok {
+		t.Fatalf("serve = %q, want miss for different Vary value", body)
+	}
+
+	uncacheable := []struct {
+		name       string
+		reqHeader  http.Header
+		respHeader http.Header
+	}{
+		{"authorization", http.Header{"Authorization": {"Bearer a"}}, nil},
+		{"cookie", http.Header{"Cookie": {"session=user-a"}}, nil},
+		{"set-cookie", nil, http.Header{"Set-Cookie": {"sid=1"}}},
+		{"private", nil, http.Header{"Cache-Control": {"max-age=60, Private"}}},
+		{"no-store", nil, http.Header{"Cache-Control": {"no-store"}}},
+		{"vary all", nil, http.Header{"Vary": {"*"}}},
+	}
+	for _, tc := range uncacheable {
+		f := newCacheFallback(config.FallbackConfig{})
+		recordResponse(f, tc.reqHeader, tc.respHeader, "secret")
+		if body, ok := serveCached(f, tc.reqHeader); ok {
+			t.Fatalf("%s: serve = %q, want not cached", tc.name, body)
+		}
+	}
+}
+
+func TestStaticFallbackDefaultStatus(t *testing.T) {
+	c, w := newGetContext(nil)
+	newStaticFallback(config.FallbackConfig{Body: "{}"}).Serve(c)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+}
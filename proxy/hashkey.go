@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/auth"
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+const (
+	HashKeyIP     = "ip"
+	HashKeyHeader = "header"
+	HashKeyQuery  = "query"
+	HashKeyCookie = "cookie"
+	HashKeyClaim  = "claim"
+)
+
+// HashKey 从请求中提取一致性哈希键，也用于灰度规则取值
+type HashKey struct {
+	source string
+	name   string
+}
+
+func NewHashKey(cfg config.HashKeyConfig) HashKey {
+	return HashKey{source: cfg.Source, name: cfg.Name}
+}
+
+// From 提取哈希键，取不到时返回空串
+func (h HashKey) From(c *gin.Context) string {
+	switch h.source {
+	case HashKeyIP:
+		return c.ClientIP()
+	case HashKeyHeader:
+		return c.GetHeader(h.name)
+	case HashKeyQuery:
+		return c.Query(h.name)
+	case HashKeyCookie:
+		v, _ := c.Cookie(h.name)
+		return v
+	case HashKeyClaim:
+		claims, ok := auth.ClaimsFromContext(c)
+		if !ok {
+			return ""
+		}
+		if v, ok := claims[h.name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	default:
+		return ""
+	}
+}
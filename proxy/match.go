@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// Predicate 单个请求条件
+type Predicate struct {
+	key    HashKey        // 取值来源
+	equals string         // 取值等于
+	regex  *regexp.Regexp // 取值匹配正则
+}
+
+func NewPredicate(cfg config.PredicateConfig) (Predicate, error) {
+	switch cfg.Source {
+	case HashKeyIP, HashKeyHeader, HashKeyQuery, HashKeyCookie, HashKeyClaim:
+	default:
+		return Predicate{}, fmt.Errorf("unknown predicate source %q", cfg.Source)
+	}
+	p := Predicate{key: HashKey{source: cfg.Source, name: cfg.Name}, equals: cfg.Equals}
+	if cfg.Regex != "" {
+		re, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return Predicate{}, err
+		}
+		p.regex = re
+	}
+	return p, nil
+}
+
+// Match 取不到值时不命中；equals 与 regex 同时配置时都需满足，都未配置时只要求取值非空
+func (p Predicate) Match(c *gin.Context) bool {
+	v := p.key.From(c)
+	if v == "" {
+		return false
+	}
+	if p.equals != "" && v != p.equals {
+		return false
+	}
+	return p.regex == nil || p.regex.MatchString(v)
+}
+
+// CanaryRule 灰度规则：条件全部满足时使用规则自己的均衡器
+type CanaryRule struct {
+	Name       string
+	Predicates []Predicate
+	Balancer   lb.Balancer
+}
+
+// Match 条件全部满足时命中，没有条件的规则不命中
+func (r CanaryRule) Match(c *gin.Context) bool {
+	if len(r.Predicates) == 0 {
+		return false
+	}
+	for _, p := range r.Predicates {
+		if !p.Match(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectBalancer 按顺序匹配灰度规则，都不命中时按目标组分流，未配置目标组时使用路由默认均衡器
+func (opts *Options) selectBalancer(c *gin.Context) lb.Balancer {
+	for _, rule := range opts.Canary {
+		if rule.Match(c) {
+			logger.Debugf("match canary rule: %s", rule.Name)
+			return rule.Balancer
+		}
+	}
+	return opts.defaultBalancer(c)
+}
+
+func (opts *Options) defaultBalancer(c *gin.Context) lb.Balancer {
+	if opts.Split != nil {
+		return opts.Split.pick(c)
+	}
+	return opts.Balancer
+}
+
+// alternates current 无可用实例时依次尝试的均衡器：灰度规则回到默认目标，目标组之间互为备份
+func (opts *Options) alternates(current lb.Balancer) []lb.Balancer {
+	if opts.Split == nil {
+		if current == opts.Balancer {
+			return nil
+		}
+		return []lb.Balancer{opts.Balancer}
+	}
+	return opts.Split.others(current)
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/auth"
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+func newMatchContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api?uid=7", nil)
+	c.Request.Header.Set("X-App-Version", "2.1.0")
+	c.Request.AddCookie(&http.Cookie{Name: "beta", Value: "on"})
+	c.Set(auth.CLAIMS_CTX_KEY, auth.JwtMapClaims{"user_type": "internal", "level": 3})
+	return c
+}
+
+func TestPredicateMatch(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  config.PredicateConfig
+		want bool
+	}{
+		{"header equals", config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Equals: "2.1.0"}, true},
+		{"header not equal", config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Equals: "2.0.0"}, false},
+		{"header regex", config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Regex: `^2\.`}, true},
+		{"header regex no match", config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Regex: `^3\.`}, false},
+		{"equals and regex", config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Equals: "2.1.0", Regex: `^3\.`}, false},
+		{"query", config.PredicateConfig{Source: HashKeyQuery, Name: "uid", Equals: "7"}, true},
+		{"cookie", config.PredicateConfig{Source: HashKeyCookie, Name: "beta", Equals: "on"}, true},
+		{"claim", config.PredicateConfig{Source: HashKeyClaim, Name: "user_type", Equals: "internal"}, true},
+		{"claim number", config.PredicateConfig{Source: HashKeyClaim, Name: "level", Equals: "3"}, true},
+		{"present", config.PredicateConfig{Source: HashKeyClaim, Name: "user_type"}, true},
+		{"missing value", config.PredicateConfig{Source: HashKeyHeader, Name: "X-Missing"}, false},
+		{"missing claim regex", config.PredicateConfig{Source: HashKeyClaim, Name: "tenant", Regex: ".*"}, false},
+		{"missing claim equals", config.PredicateConfig{Source: HashKeyClaim, Name: "tenant", Equals: "a"}, false},
+	}
+	c := newMatchContext()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewPredicate(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Match(c); got != tc.want {
+				t.Fatalf("match = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPredicateInvalid(t *testing.T) {
+	for _, cfg := range []config.PredicateConfig{
+		{Source: "body", Name: "x"},
+		{Source: HashKeyHeader, Name: "x", Regex: "("},
+	} {
+		if _, err := NewPredicate(cfg); err == nil {
+			t.Fatalf("NewPredicate(%+v) accepted invalid config", cfg)
+		}
+	}
+}
+
+func TestCanaryRuleMatch(t *testing.T) {
+	c := newMatchContext()
+	header, _ := NewPredicate(config.PredicateConfig{Source: HashKeyHeader, Name: "X-App-Version", Regex: `^2\.`})
+	claim, _ := NewPredicate(config.PredicateConfig{Source: HashKeyClaim, Name: "user_type", Equals: "external"})
+	if !(CanaryRule{Predicates: []Predicate{header}}).Match(c) {
+		t.Fatal("rule with matching predicate should match")
+	}
+	if (CanaryRule{Predicates: []Predicate{header, claim}}).Match(c) {
+		t.Fatal("rule should require all predicates")
+	}
+	if (CanaryRule{}).Match(c) {
+		t.Fatal("rule without predicates should not match")
+	}
+}
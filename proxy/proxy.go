@@ -1,14 +1,19 @@
 package proxy
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hellobchain/gateway-server/pkg/auth"
 	"github.com/hellobchain/gateway-server/pkg/breaker"
+	"github.com/hellobchain/gateway-server/pkg/config"
 	"github.com/hellobchain/gateway-server/pkg/lb"
 	"github.com/hellobchain/wswlog/wlogging"
 )
@@ -28,41 +33,213 @@ func Handler(p *httputil.ReverseProxy) gin.HandlerFunc {
 	}
 }
 
-func LbHandler(lbBalancer lb.Balancer, sreBreaker *breaker.SreBreaker) gin.HandlerFunc {
+// Options 路由转发参数
+type Options struct {
+	Balancer lb.Balancer    // 负载均衡
+	Canary   []CanaryRule   // 灰度规则，按顺序匹配，都不命中时使用 Split 或 Balancer
+	Split    *Splitter      // 目标组分流，为空时使用 Balancer
+	Breakers *breaker.Group // 该路由的熔断器集合
+	Failure  FailurePolicy  // 熔断失败判定
+	Retry    RetryPolicy    // 失败重试
+	Timeout  TimeoutPolicy  // 转发超时
+	Proxies  *ProxyPool     // 按实例复用的反向代理，为空时使用默认连接参数
+	Fallback Fallback       // 降级处理，可为空
+	HashKey  HashKey        // 一致性哈希键来源
+	Rewrite  Rewrite        // 路径与查询参数改写
+
+	MaxBodyBytes int64 // 请求体上限，为 0 不限制
+}
+
+// LbHandler 负载均衡转发，失败时按重试策略换节点重试
+func LbHandler(opts Options) gin.HandlerFunc {
+	if opts.Proxies == nil {
+		opts.Proxies = NewProxyPool(config.TransportConfig{})
+	}
 	return func(c *gin.Context) {
-		addr, ok, isRemovePrex := lbBalancer.Pick()
-		if !ok {
-			auth.ResultCode(c, http.StatusServiceUnavailable, "no available instance")
+		if !limitBody(c, opts.MaxBodyBytes) {
 			return
 		}
-		logger.Debugf("pick instance: %s", addr)
-		p := NewReverseProxy(addr)
-		if isRemovePrex {
-			prefix := c.Param("proxyPath")
-			if prefix != "" && prefix[0] == '/' {
-				prefix = prefix[1:]
+		ctx, cancel := opts.Timeout.withTotal(c.Request.Context())
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		info := lb.PickInfo{HashKey: opts.HashKey.From(c)}
+		attempts := 1
+		var body []byte
+		if opts.Retry.allow(c.Request.Method) {
+			var ok bool
+			if body, ok = opts.Retry.bufferBody(c.Request); ok {
+				attempts = opts.Retry.attempts
 			}
-			targetPath := "/" + prefix
-			c.Request.URL.Path = targetPath
-			logger.Debugf("real router path: %s", targetPath)
-			c.Request.URL.Path = targetPath
 		}
-		if sreBreaker.Enabled() {
-			p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-				w.WriteHeader(http.StatusBadGateway)
+		path := c.Request.URL.Path
+		balancer := opts.selectBalancer(c)
+		// 灰度规则、分流与哈希键都按客户端原始查询参数判断，选定目标后再改写
+		opts.Rewrite.rewriteQuery(c.Request.URL)
+		for n := 1; ; n++ {
+			inst, ok := pick(balancer, info)
+			for _, alt := range opts.alternates(balancer) {
+				if ok {
+					break
+				}
+				logger.Warnf("no available instance in selected targets for %s, try other targets", path)
+				balancer = alt
+				inst, ok = pick(balancer, info)
+			}
+			if !ok {
+				degrade(c, opts.Fallback, "no available instance")
+				return
 			}
-			err := sreBreaker.Do(func() error {
-				p.ServeHTTP(c.Writer, c.Request)
-				if c.Writer.Status() >= 500 {
-					return fmt.Errorf("backend 5xx")
+			if body != nil {
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				c.Request.ContentLength = int64(len(body))
+			}
+			c.Request.URL.Path = opts.Rewrite.path(targetPath(c, inst, path))
+			status := forward(c, &opts, balancer, inst, n == attempts)
+			if status == 0 {
+				return
+			}
+			logger.Warnf("retry %s %s, attempt %d on %s failed with %d", c.Request.Method, path, n, inst.Addr, status)
+			if info.Exclude == nil {
+				info.Exclude = make(map[string]bool, attempts)
+			}
+			info.Exclude[inst.Addr] = true
+			if !opts.Retry.wait(ctx, n) {
+				// 退避期间整个请求已超时
+				if ctx.Err() == context.DeadlineExceeded {
+					status = http.StatusGatewayTimeout
 				}
-				return nil
-			})
-			if err == breaker.ErrBreakerOpen {
-				auth.ResultCode(c, http.StatusServiceUnavailable, "circuit breaker open")
+				c.AbortWithStatus(status)
+				return
 			}
-		} else {
-			p.ServeHTTP(c.Writer, c.Request)
 		}
 	}
 }
+
+// limitBody 已知长度超限时直接返回 413；分块传输的请求体在读取超限时由 errorHandler 返回 413
+func limitBody(c *gin.Context, limit int64) bool {
+	if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return true
+	}
+	if c.Request.ContentLength > limit {
+		auth.ResultStatus(c, http.StatusRequestEntityTooLarge, "request body too large")
+		return false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	return true
+}
+
+// pick 优先选择未尝试过的节点，都已尝试过时允许重复选择
+func pick(b lb.Balancer, info lb.PickInfo) (lb.Instance, bool) {
+	inst, ok := b.Pick(info)
+	if ok || len(info.Exclude) == 0 {
+		return inst, ok
+	}
+	info.Exclude = nil
+	return b.Pick(info)
+}
+
+// targetPath 转发到节点的路径，节点配置移除前缀时只保留路由前缀之后的部分
+func targetPath(c *gin.Context, inst lb.Instance, path string) string {
+	if !inst.IsRemovePrex {
+		return path
+	}
+	prefix := c.Param("proxyPath")
+	if prefix != "" && prefix[0] == '/' {
+		prefix = prefix[1:]
+	}
+	targetPath := "/" + prefix
+	logger.Debugf("real router path: %s", targetPath)
+	return targetPath
+}
+
+// attempt 单次转发的状态，经请求 context 传给共享的 ReverseProxy 回调
+type attempt struct {
+	addr        string // 节点地址
+	retry       *RetryPolicy
+	final       bool  // 是否最后一次尝试
+	upstreamErr error // 已归类的上游错误
+	status      int   // 失败时的状态码
+	retrying    bool  // 失败且可重试，响应未写出
+}
+
+type attemptKey struct{}
+
+func attemptFrom(r *http.Request) *attempt {
+	a, _ := r.Context().Value(attemptKey{}).(*attempt)
+	return a
+}
+
+// modifyResponse 非最后一次尝试遇到可重试状态码时丢弃响应
+func modifyResponse(resp *http.Response) error {
+	a := attemptFrom(resp.Request)
+	if a != nil && !a.final && a.retry.retryStatus(resp.StatusCode) {
+		a.status, a.retrying = resp.StatusCode, true
+		return errRetryStatus
+	}
+	return nil
+}
+
+func errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errRetryStatus) {
+		return
+	}
+	upstreamErr := upstreamError(err)
+	status := errorStatus(upstreamErr)
+	a := attemptFrom(r)
+	if a == nil {
+		logger.Errorf("proxy error: %v", err)
+		w.WriteHeader(status)
+		return
+	}
+	logger.Errorf("proxy %s error: %v", a.addr, err)
+	a.upstreamErr, a.status = upstreamErr, status
+	if !a.final && a.retry.retryError(upstreamErr) {
+		a.retrying = true
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// forward 向节点转发一次请求。返回 0 表示响应已写出（含降级）；
+// 非最后一次尝试且失败可重试时不写响应，返回放弃重试时应使用的状态码
+func forward(c *gin.Context, opts *Options, balancer lb.Balancer, inst lb.Instance, final bool) int {
+	addr := inst.URL()
+	logger.Debugf("pick instance: %s", addr)
+	p := opts.Proxies.Get(inst)
+	a := &attempt{addr: addr, retry: &opts.Retry, final: final}
+	ctx, cancel := opts.Timeout.withPerTry(context.WithValue(c.Request.Context(), attemptKey{}, a))
+	defer cancel()
+	opts.Timeout.propagate(ctx, c.Request.Header)
+	req := c.Request.WithContext(ctx)
+	// 转发结果回传负载均衡器，用于最少连接、延迟感知等策略
+	start := time.Now()
+	result := lb.ErrSkipped
+	defer func() {
+		balancer.Done(inst, time.Since(start), result)
+	}()
+	serve := func() error {
+		if rec, ok := opts.Fallback.(recorder); ok {
+			defer rec.record(c)()
+		}
+		p.ServeHTTP(c.Writer, req)
+		if a.status == 0 {
+			a.status = c.Writer.Status()
+		}
+		result = opts.Failure.Classify(a.status, a.upstreamErr)
+		return result
+	}
+	if !opts.Breakers.Enabled() {
+		_ = serve()
+	} else if err := opts.Breakers.Get(addr).Do(serve); err == breaker.ErrBreakerOpen {
+		// 按实例熔断时换节点重试，路由级熔断时直接降级
+		if !final && opts.Breakers.PerInstance() {
+			return http.StatusServiceUnavailable
+		}
+		degrade(c, opts.Fallback, "circuit breaker open")
+		return 0
+	}
+	if a.retrying {
+		return a.status
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/breaker"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// newBenchGateway 启动上游与网关，返回网关地址
+func newBenchGateway(b *testing.B, handler func(lb.Instance) gin.HandlerFunc) string {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	}))
+	b.Cleanup(upstream.Close)
+	inst := lb.Instance{Addr: upstream.Listener.Addr().String(), Weight: 1}
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Any("/bench/*proxyPath", handler(inst))
+	gw := httptest.NewServer(r)
+	b.Cleanup(gw.Close)
+	return gw.URL + "/bench/ping"
+}
+
+func runBench(b *testing.B, url string) {
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 256}}
+	b.Cleanup(client.CloseIdleConnections)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := client.Get(url)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	})
+}
+
+// BenchmarkProxyPerRequest 每个请求新建 ReverseProxy 并使用 http.DefaultTransport（旧实现）
+func BenchmarkProxyPerRequest(b *testing.B) {
+	url := newBenchGateway(b, func(inst lb.Instance) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			NewReverseProxy(inst.URL()).ServeHTTP(c.Writer, c.Request)
+		}
+	})
+	runBench(b, url)
+}
+
+// BenchmarkProxyPooled 按实例复用 ReverseProxy 与独立 Transport
+func BenchmarkProxyPooled(b *testing.B) {
+	url := newBenchGateway(b, func(inst lb.Instance) gin.HandlerFunc {
+		return LbHandler(Options{
+			Balancer: lb.New([]lb.Instance{inst}),
+			Breakers: breaker.NewGroup("bench", breaker.Settings{}, false),
+			Proxies:  NewProxyPool(config.TransportConfig{}),
+		})
+	})
+	runBench(b, url)
+}
@@ -0,0 +1,31 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/breaker"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// newUpstream 启动上游并返回对应实例
+func newUpstream(t *testing.T, handler http.HandlerFunc) lb.Instance {
+	s := httptest.NewServer(handler)
+	t.Cleanup(s.Close)
+	return lb.Instance{Addr: s.Listener.Addr().String(), Weight: 1}
+}
+
+// newTestGateway 以 opts 启动挂在 /api 下的网关，返回网关地址
+func newTestGateway(t *testing.T, opts Options) string {
+	if opts.Breakers == nil {
+		opts.Breakers = breaker.NewGroup("test", breaker.Settings{}, false)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Any("/api/*proxyPath", LbHandler(opts))
+	gw := httptest.NewServer(r)
+	t.Cleanup(gw.Close)
+	return gw.URL
+}
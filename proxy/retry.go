@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+const (
+	RetryOnConnectFailure = "connect-failure" // 连接失败
+	RetryOnTimeout        = "timeout"         // 单次尝试超时
+	RetryOn5xx            = "5xx"             // 任意 5xx
+
+	defaultRetryBackoff    = 25 * time.Millisecond
+	defaultRetryMaxBackoff = 250 * time.Millisecond
+	defaultBufferBodyBytes = 64 << 10
+)
+
+// errRetryStatus 可重试的响应码，由 ModifyResponse 返回以丢弃该响应
+var errRetryStatus = errors.New("retryable upstream status")
+
+// RetryPolicy 失败重试策略，零值表示不重试
+type RetryPolicy struct {
+	attempts       int          // 最大尝试次数（含首次）
+	connectFailure bool         // 连接失败重试
+	timeout        bool         // 单次尝试超时重试
+	all5xx         bool         // 任意 5xx 重试
+	statusCodes    map[int]bool // 重试的状态码
+	nonIdempotent  bool         // 非幂等方法也重试
+	backoff        time.Duration
+	maxBackoff     time.Duration
+	bufferBytes    int64 // 可重放的请求体上限
+}
+
+func NewRetryPolicy(cfg config.RetryConfig) RetryPolicy {
+	p := RetryPolicy{
+		attempts:      cfg.Attempts,
+		nonIdempotent: cfg.NonIdempotent,
+		backoff:       cfg.Backoff,
+		maxBackoff:    cfg.MaxBackoff,
+		bufferBytes:   cfg.BufferBodyBytes,
+		statusCodes:   make(map[int]bool),
+	}
+	if p.backoff <= 0 {
+		p.backoff = defaultRetryBackoff
+	}
+	if p.maxBackoff <= 0 {
+		p.maxBackoff = defaultRetryMaxBackoff
+	}
+	if p.bufferBytes <= 0 {
+		p.bufferBytes = defaultBufferBodyBytes
+	}
+	retryOn := cfg.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = []string{RetryOnConnectFailure, "502", "503"}
+	}
+	for _, cond := range retryOn {
+		switch cond = strings.ToLower(strings.TrimSpace(cond)); cond {
+		case RetryOnConnectFailure:
+			p.connectFailure = true
+		case RetryOnTimeout:
+			p.timeout = true
+		case RetryOn5xx:
+			p.all5xx = true
+		default:
+			code, err := strconv.Atoi(cond)
+			if err != nil || code < 100 || code > 599 {
+				logger.Warnf("ignore invalid retry_on condition: %s", cond)
+				continue
+			}
+			p.statusCodes[code] = true
+		}
+	}
+	return p
+}
+
+// allow 请求方法是否允许重试
+func (p RetryPolicy) allow(method string) bool {
+	if p.attempts < 2 {
+		return false
+	}
+	if p.nonIdempotent {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryError 已归类的上游错误是否重试，客户端取消不重试
+func (p RetryPolicy) retryError(err error) bool {
+	switch {
+	case errors.Is(err, ErrUpstreamTimeout):
+		return p.timeout
+	case errors.Is(err, ErrUpstreamConnect):
+		return p.connectFailure
+	default:
+		return false
+	}
+}
+
+// retryStatus 响应码是否重试
+func (p RetryPolicy) retryStatus(code int) bool {
+	return p.statusCodes[code] || p.all5xx && code >= http.StatusInternalServerError
+}
+
+// bufferBody 读取请求体以便重放，超过上限时还原请求体并返回 false
+func (p RetryPolicy) bufferBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > p.bufferBytes {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, p.bufferBytes+1))
+	if err != nil || int64(len(body)) > p.bufferBytes {
+		// 已读部分放回，剩余部分继续从原请求体读取
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false
+	}
+	_ = r.Body.Close()
+	return body, true
+}
+
+// wait 第 n 次重试前退避，退避时间指数增长并取 [d/2, d] 的随机值；客户端断开时返回 false
+func (p RetryPolicy) wait(ctx context.Context, n int) bool {
+	d := p.backoff << (n - 1)
+	if d <= 0 || d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	d = d/2 + rand.N(d/2+1)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// deadInstance 已关闭端口的实例，连接失败
+func deadInstance(t *testing.T) lb.Instance {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return lb.Instance{Addr: addr, Weight: 1}
+}
+
+// echoUpstream 返回请求体并统计请求数
+func echoUpstream(t *testing.T, hits *atomic.Int32) lb.Instance {
+	return newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+}
+
+func statusUpstream(t *testing.T, status int, hits *atomic.Int32) lb.Instance {
+	return newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(http.StatusText(status)))
+	})
+}
+
+func send(t *testing.T, method, url, body string) (int, string) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestRetryConnectFailureReplaysBody(t *testing.T) {
+	var hits atomic.Int32
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{deadInstance(t), echoUpstream(t, &hits)}),
+		Retry:    NewRetryPolicy(config.RetryConfig{Attempts: 2, NonIdempotent: true, Backoff: time.Millisecond}),
+	})
+	// 轮询下一半请求先落到不可用实例，重试时换到未尝试过的实例并重放请求体
+	for i := 0; i < 4; i++ {
+		body := strings.Repeat("x", i+1)
+		if code, got := send(t, http.MethodPost, gw+"/api/echo", body); code != http.StatusOK || got != body {
+			t.Fatalf("request %d = %d %q, want 200 %q", i, code, got, body)
+		}
+	}
+	if n := hits.Load(); n != 4 {
+		t.Fatalf("live instance hits = %d, want 4", n)
+	}
+}
+
+func TestRetryOnStatusDiscardsResponse(t *testing.T) {
+	var bad, good atomic.Int32
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{statusUpstream(t, http.StatusServiceUnavailable, &bad), statusUpstream(t, http.StatusOK, &good)}),
+		Retry:    NewRetryPolicy(config.RetryConfig{Attempts: 2, Backoff: time.Millisecond}),
+	})
+	for i := 0; i < 4; i++ {
+		if code, got := send(t, http.MethodGet, gw+"/api/x", ""); code != http.StatusOK || got != "OK" {
+			t.Fatalf("request %d = %d %q, want 200 OK", i, code, got)
+		}
+	}
+	if bad.Load() != 2 || good.Load() != 4 {
+		t.Fatalf("hits = %d bad, %d good, want 2 and 4", bad.Load(), good.Load())
+	}
+}
+
+func TestRetryFinalAttemptResponse(t *testing.T) {
+	var hits atomic.Int32
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{
+			statusUpstream(t, http.StatusBadGateway, &hits),
+			statusUpstream(t, http.StatusServiceUnavailable, &hits),
+		}),
+		Retry: NewRetryPolicy(config.RetryConfig{Attempts: 2, Backoff: time.Millisecond}),
+	})
+	// 全部失败时返回最后一次尝试的上游响应
+	code, got := send(t, http.MethodGet, gw+"/api/x", "")
+	if hits.Load() != 2 || code < http.StatusBadGateway || got != http.StatusText(code) {
+		t.Fatalf("response = %d %q after %d attempts, want last upstream response after 2", code, got, hits.Load())
+	}
+}
+
+func TestRetryNonIdempotentNotRetriedByDefault(t *testing.T) {
+	var hits atomic.Int32
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{deadInstance(t), echoUpstream(t, &hits)}),
+		Retry:    NewRetryPolicy(config.RetryConfig{Attempts: 3, Backoff: time.Millisecond}),
+	})
+	codes := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		code, _ := send(t, http.MethodPost, gw+"/api/echo", "data")
+		codes[code]++
+	}
+	// 轮询两次各落到一个实例，POST 不重试
+	if codes[http.StatusOK] != 1 || codes[http.StatusBadGateway] != 1 || hits.Load() != 1 {
+		t.Fatalf("codes = %v, live hits = %d, want one 200 and one 502", codes, hits.Load())
+	}
+}
+
+func TestRetryBackoffStopsAtDeadline(t *testing.T) {
+	var hits atomic.Int32
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{statusUpstream(t, http.StatusServiceUnavailable, &hits)}),
+		Retry:    NewRetryPolicy(config.RetryConfig{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}),
+		Timeout:  NewTimeoutPolicy(config.TimeoutConfig{Total: 50 * time.Millisecond}),
+	})
+	start := time.Now()
+	code, _ := send(t, http.MethodGet, gw+"/api/x", "")
+	if code != http.StatusGatewayTimeout || time.Since(start) > 5*time.Second || hits.Load() != 1 {
+		t.Fatalf("status = %d after %v and %d attempts, want 504 once the deadline passes during backoff",
+			code, time.Since(start), hits.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if NewRetryPolicy(config.RetryConfig{Backoff: time.Hour}).wait(ctx, 1) {
+		t.Fatal("wait on canceled context should return false")
+	}
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// Rewrite 转发前改写路径与查询参数，零值不做任何改写
+type Rewrite struct {
+	from, to    string         // 前缀替换，from 为空时不替换
+	regex       *regexp.Regexp // 路径正则
+	replacement string         // 正则替换结果
+	addPrefix   string         // 追加的前缀
+	query       config.QueryRewriteConfig
+}
+
+// NewRewrite routePath 为路由 path，作为前缀替换 from 的默认值
+func NewRewrite(cfg config.RewriteConfig, routePath string) (Rewrite, error) {
+	r := Rewrite{
+		addPrefix: strings.TrimSuffix(cfg.AddPrefix, "/"),
+		query:     cfg.Query,
+	}
+	if p := cfg.ReplacePrefix; p.From != "" || p.To != "" {
+		r.from, r.to = p.From, strings.TrimSuffix(p.To, "/")
+		if r.from == "" {
+			r.from = routePath
+		}
+		r.from = strings.TrimSuffix(r.from, "/")
+	}
+	if cfg.Regex != "" {
+		re, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return Rewrite{}, err
+		}
+		r.regex, r.replacement = re, cfg.Replacement
+	}
+	return r, nil
+}
+
+// path 依次执行前缀替换、正则替换与追加前缀
+func (r Rewrite) path(p string) string {
+	if r.from != "" && (p == r.from || strings.HasPrefix(p, r.from+"/")) {
+		p = r.to + p[len(r.from):]
+	}
+	if r.regex != nil && r.regex.MatchString(p) {
+		p = r.regex.ReplaceAllString(p, r.replacement)
+	}
+	if r.addPrefix != "" {
+		p = r.addPrefix + p
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// rewriteQuery 依次删除、改名、追加查询参数，未配置时保留原始编码
+func (r Rewrite) rewriteQuery(u *url.URL) {
+	q := r.query
+	if len(q.Remove) == 0 && len(q.Rename) == 0 && len(q.Add) == 0 {
+		return
+	}
+	values := u.Query()
+	for _, name := range q.Remove {
+		values.Del(name)
+	}
+	for _, rn := range q.Rename {
+		if v, ok := values[rn.From]; ok {
+			values.Del(rn.From)
+			values[rn.To] = v
+		}
+	}
+	for _, p := range q.Add {
+		values.Set(p.Name, p.Value)
+	}
+	u.RawQuery = values.Encode()
+}
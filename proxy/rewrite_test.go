@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+func TestRewritePath(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  config.RewriteConfig
+		path string
+		want string
+	}{
+		{"none", config.RewriteConfig{}, "/dm/a", "/dm/a"},
+		{"replace route prefix", config.RewriteConfig{ReplacePrefix: config.PrefixRewriteConfig{To: "/api/"}}, "/dm/a", "/api/a"},
+		{"replace segment only", config.RewriteConfig{ReplacePrefix: config.PrefixRewriteConfig{To: "/api"}}, "/dmx/a", "/dmx/a"},
+		{"remove prefix", config.RewriteConfig{ReplacePrefix: config.PrefixRewriteConfig{From: "/dm/v1"}}, "/dm/v1", "/"},
+		{"regex", config.RewriteConfig{Regex: `^/api/v1/(.*)`, Replacement: "/v2/$1"}, "/api/v1/users/1", "/v2/users/1"},
+		{"regex no match", config.RewriteConfig{Regex: `^/api/v1/(.*)`, Replacement: "/v2/$1"}, "/api/v3/users", "/api/v3/users"},
+		{"add prefix", config.RewriteConfig{AddPrefix: "/internal/"}, "/dm/a", "/internal/dm/a"},
+		{"combined", config.RewriteConfig{
+			ReplacePrefix: config.PrefixRewriteConfig{To: "/api/v1"},
+			Regex:         `^/api/v1/(.*)`,
+			Replacement:   "/v2/$1",
+			AddPrefix:     "/svc",
+		}, "/dm/users", "/svc/v2/users"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRewrite(tc.cfg, "/dm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.path(tc.path); got != tc.want {
+				t.Fatalf("path(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+	if _, err := NewRewrite(config.RewriteConfig{Regex: "("}, "/dm"); err == nil {
+		t.Fatal("invalid regex accepted")
+	}
+}
+
+func TestRewriteQuery(t *testing.T) {
+	r, _ := NewRewrite(config.RewriteConfig{Query: config.QueryRewriteConfig{
+		Remove: []string{"debug"},
+		Rename: []config.QueryRenameConfig{{From: "uid", To: "userId"}},
+		Add:    []config.QueryParamConfig{{Name: "source", Value: "gateway"}, {Name: "v", Value: "2"}},
+	}}, "/dm")
+	u, _ := url.Parse("/dm?debug=1&uid=7&uid=8&v=1&keep=a+b")
+	r.rewriteQuery(u)
+	if want := "keep=a+b&source=gateway&userId=7&userId=8&v=2"; u.RawQuery != want {
+		t.Fatalf("query = %q, want %q", u.RawQuery, want)
+	}
+
+	// 未配置查询参数改写时保留原始编码
+	r, _ = NewRewrite(config.RewriteConfig{}, "/dm")
+	u, _ = url.Parse("/dm?b=2&a=%7E")
+	r.rewriteQuery(u)
+	if u.RawQuery != "b=2&a=%7E" {
+		t.Fatalf("query = %q, want unchanged", u.RawQuery)
+	}
+}
+
+func TestRewriteQueryAfterCanaryMatch(t *testing.T) {
+	query := make(chan string, 1)
+	record := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			query <- name + " " + r.URL.RawQuery
+		}
+	}
+	stable, canary := newUpstream(t, record("stable")), newUpstream(t, record("canary"))
+	p, _ := NewPredicate(config.PredicateConfig{Source: HashKeyQuery, Name: "uid", Equals: "7"})
+	rw, _ := NewRewrite(config.RewriteConfig{Query: config.QueryRewriteConfig{
+		Rename: []config.QueryRenameConfig{{From: "uid", To: "userId"}},
+	}}, "/api")
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{stable}),
+		Canary:   []CanaryRule{{Name: "uid", Predicates: []Predicate{p}, Balancer: lb.New([]lb.Instance{canary})}},
+		Rewrite:  rw,
+	})
+	resp, err := http.Get(gw + "/api/x?uid=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// 灰度规则按原始参数 uid 命中，上游收到改名后的 userId
+	if got := <-query; got != "canary userId=7" {
+		t.Fatalf("upstream got %q, want canary userId=7", got)
+	}
+}
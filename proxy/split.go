@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+// TargetGroup 命名目标组
+type TargetGroup struct {
+	Name     string
+	Balancer lb.Balancer
+}
+
+// GroupWeight 目标组当前分流比例
+type GroupWeight struct {
+	Name    string `json:"name"`
+	Percent int    `json:"percent"`
+}
+
+// Splitter 按百分比在目标组间分流，比例可运行时调整；
+// 配置会话保持键时同一键固定落到同一组，调整比例只影响边界附近的键
+type Splitter struct {
+	groups  []TargetGroup
+	percent atomic.Pointer[[]int] // 与 groups 一一对应
+	sticky  HashKey
+}
+
+func NewSplitter(groups []TargetGroup, percents []int, sticky config.HashKeyConfig) *Splitter {
+	s := &Splitter{groups: groups, sticky: NewHashKey(sticky)}
+	p := append([]int(nil), percents...)
+	s.percent.Store(&p)
+	return s
+}
+
+// Weights 当前分流比例
+func (s *Splitter) Weights() []GroupWeight {
+	percents := *s.percent.Load()
+	list := make([]GroupWeight, len(s.groups))
+	for i, g := range s.groups {
+		list[i] = GroupWeight{Name: g.Name, Percent: percents[i]}
+	}
+	return list
+}
+
+// SetWeights 调整分流比例，未指定的组保持原比例
+func (s *Splitter) SetWeights(weights map[string]int) error {
+	percents := append([]int(nil), *s.percent.Load()...)
+	for name, w := range weights {
+		i := s.index(name)
+		if i < 0 {
+			return fmt.Errorf("target group %s not found", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("invalid percent %d for group %s", w, name)
+		}
+		percents[i] = w
+	}
+	s.percent.Store(&percents)
+	return nil
+}
+
+func (s *Splitter) index(name string) int {
+	for i, g := range s.groups {
+		if g.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// pick 选择目标组；比例总和不必为 100，按各组占总和的比例分流，全为 0 时使用第一组
+func (s *Splitter) pick(c *gin.Context) lb.Balancer {
+	percents := *s.percent.Load()
+	total := 0
+	for _, p := range percents {
+		total += p
+	}
+	if total <= 0 {
+		return s.groups[0].Balancer
+	}
+	var n int
+	if key := s.sticky.From(c); key != "" {
+		n = int(crc32.ChecksumIEEE([]byte(key)) % uint32(total))
+	} else {
+		n = rand.IntN(total)
+	}
+	for i, p := range percents {
+		if n < p {
+			return s.groups[i].Balancer
+		}
+		n -= p
+	}
+	return s.groups[len(s.groups)-1].Balancer
+}
+
+// others 除 current 外仍有流量比例的目标组，current 无可用实例时依次尝试
+func (s *Splitter) others(current lb.Balancer) []lb.Balancer {
+	percents := *s.percent.Load()
+	var list []lb.Balancer
+	for i, g := range s.groups {
+		if g.Balancer != current && percents[i] > 0 {
+			list = append(list, g.Balancer)
+		}
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+func newSplitContext(user string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if user != "" {
+		c.Request.Header.Set("X-User", user)
+	}
+	return c
+}
+
+func newTestSplitter(sticky config.HashKeyConfig) (*Splitter, map[lb.Balancer]string) {
+	stable := lb.New([]lb.Instance{{Addr: "stable", Weight: 1}})
+	canary := lb.New([]lb.Instance{{Addr: "canary", Weight: 1}})
+	s := NewSplitter([]TargetGroup{{Name: "stable", Balancer: stable}, {Name: "canary", Balancer: canary}}, []int{90, 10}, sticky)
+	return s, map[lb.Balancer]string{stable: "stable", canary: "canary"}
+}
+
+func TestSplitterPercent(t *testing.T) {
+	s, names := newTestSplitter(config.HashKeyConfig{})
+	count := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		count[names[s.pick(newSplitContext(""))]]++
+	}
+	if count["canary"] < 800 || count["canary"] > 1200 {
+		t.Fatalf("distribution = %v, want about 10%% canary", count)
+	}
+
+	if err := s.SetWeights(map[string]int{"stable": 0}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if name := names[s.pick(newSplitContext(""))]; name != "canary" {
+			t.Fatalf("pick = %s after shifting all traffic to canary", name)
+		}
+	}
+	if err := s.SetWeights(map[string]int{"blue": 10}); err == nil {
+		t.Fatal("unknown group should fail")
+	}
+}
+
+func TestSplitterSticky(t *testing.T) {
+	s, names := newTestSplitter(config.HashKeyConfig{Source: HashKeyHeader, Name: "X-User"})
+	first := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		user := "user-" + strconv.Itoa(i)
+		first[user] = names[s.pick(newSplitContext(user))]
+	}
+	for i := 0; i < 5; i++ {
+		for user, group := range first {
+			if got := names[s.pick(newSplitContext(user))]; got != group {
+				t.Fatalf("%s moved from %s to %s", user, group, got)
+			}
+		}
+	}
+	// 扩大灰度比例时原灰度用户仍留在灰度组
+	if err := s.SetWeights(map[string]int{"stable": 50, "canary": 50}); err != nil {
+		t.Fatal(err)
+	}
+	for user, group := range first {
+		if group == "canary" && names[s.pick(newSplitContext(user))] != "canary" {
+			t.Fatalf("%s left canary after increasing its percent", user)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// TimeoutPolicy 转发超时，超时按上游超时处理：返回 504 并计为熔断失败
+type TimeoutPolicy struct {
+	total  time.Duration // 整个请求（含重试与退避）的超时
+	perTry time.Duration // 单次尝试超时
+	header string        // 向上游传递剩余时间的请求头
+}
+
+func NewTimeoutPolicy(cfg config.TimeoutConfig) TimeoutPolicy {
+	return TimeoutPolicy{total: cfg.Total, perTry: cfg.PerTry, header: http.CanonicalHeaderKey(cfg.Header)}
+}
+
+// withTotal 为整个请求设置截止时间
+func (p TimeoutPolicy) withTotal(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.total <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.total)
+}
+
+// withPerTry 为单次尝试设置截止时间，不会晚于整个请求的截止时间
+func (p TimeoutPolicy) withPerTry(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.perTry <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.perTry)
+}
+
+// propagate 将本次尝试的剩余时间（毫秒）写入请求头，供上游按时放弃处理
+func (p TimeoutPolicy) propagate(ctx context.Context, header http.Header) {
+	if p.header == "" {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 1 {
+		remaining = 1
+	}
+	header.Set(p.header, strconv.FormatInt(remaining, 10))
+}
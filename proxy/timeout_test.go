@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+func TestTimeoutReturnsGatewayTimeout(t *testing.T) {
+	slow := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{slow}),
+		Timeout:  NewTimeoutPolicy(config.TimeoutConfig{Total: 50 * time.Millisecond}),
+	})
+	start := time.Now()
+	if code, _ := send(t, http.MethodGet, gw+"/api/x", ""); code != http.StatusGatewayTimeout || time.Since(start) > 2*time.Second {
+		t.Fatalf("status = %d after %v, want 504 once the deadline passes", code, time.Since(start))
+	}
+}
+
+func TestTimeoutPropagatesRemaining(t *testing.T) {
+	got := make(chan string, 1)
+	up := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get("X-Request-Timeout")
+	})
+	gw := newTestGateway(t, Options{
+		Balancer: lb.New([]lb.Instance{up}),
+		Timeout: NewTimeoutPolicy(config.TimeoutConfig{
+			Total:  10 * time.Second,
+			PerTry: 500 * time.Millisecond,
+			Header: "x-request-timeout",
+		}),
+	})
+	send(t, http.MethodGet, gw+"/api/x", "")
+	// 上游收到的是单次尝试的剩余毫秒数
+	ms, err := strconv.Atoi(<-got)
+	if err != nil || ms <= 0 || ms > 500 {
+		t.Fatalf("X-Request-Timeout = %d, %v, want remaining per-try milliseconds", ms, err)
+	}
+}
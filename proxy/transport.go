@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	copyBufferSize             = 32 << 10
+)
+
+// NewTransport 按路由配置创建上游连接池，未配置的参数与 http.DefaultTransport 一致；
+// tlsConf 为 https 上游的 TLS 客户端配置，可为空
+func NewTransport(cfg config.TransportConfig, tlsConf *tls.Config) *http.Transport {
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	dialer := &net.Dialer{
+		Timeout:   orDefault(cfg.DialTimeout, defaultDialTimeout),
+		KeepAlive: orDefault(cfg.KeepAlive, defaultKeepAlive),
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdle, // 每个实例独立连接池，不再受默认每主机 2 个空闲连接限制
+		MaxConnsPerHost:       cfg.MaxConns,
+		IdleConnTimeout:       orDefault(cfg.IdleConnTimeout, defaultIdleConnTimeout),
+		TLSHandshakeTimeout:   orDefault(cfg.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     cfg.HTTP2 == nil || *cfg.HTTP2,
+	}
+	if tlsConf != nil {
+		// 同一配置被多个实例共用，HTTP/2 协商会修改 NextProtos，需复制
+		t.TLSClientConfig = tlsConf.Clone()
+	}
+	if cfg.H2C {
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetUnencryptedHTTP2(true)
+		if t.ForceAttemptHTTP2 {
+			t.Protocols.SetHTTP2(true)
+		}
+		t.Protocols.SetHTTP1(true)
+	}
+	return t
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// ProxyPool 按实例缓存反向代理，每个实例独享一个 Transport
+type ProxyPool struct {
+	cfg config.TransportConfig
+
+	mu      sync.RWMutex
+	proxies map[string]*httputil.ReverseProxy // url -> proxy
+	closed  bool
+}
+
+func NewProxyPool(cfg config.TransportConfig) *ProxyPool {
+	return &ProxyPool{cfg: cfg, proxies: make(map[string]*httputil.ReverseProxy)}
+}
+
+// Get 返回实例的反向代理，首次使用时创建
+func (p *ProxyPool) Get(inst lb.Instance) *httputil.ReverseProxy {
+	addr := inst.URL()
+	p.mu.RLock()
+	rp, ok := p.proxies[addr]
+	p.mu.RUnlock()
+	if ok {
+		return rp
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rp, ok = p.proxies[addr]; ok {
+		return rp
+	}
+	rp = NewReverseProxy(addr)
+	rp.Transport = NewTransport(p.cfg, inst.TLS)
+	rp.BufferPool = copyBuffers
+	rp.ModifyResponse = modifyResponse
+	rp.ErrorHandler = errorHandler
+	// 路由已下线时仍允许在途请求完成，但不再保留连接
+	if p.closed {
+		rp.Transport.(*http.Transport).DisableKeepAlives = true
+		return rp
+	}
+	p.proxies[addr] = rp
+	return rp
+}
+
+// Close 关闭所有实例的空闲连接，路由下线时调用
+func (p *ProxyPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, rp := range p.proxies {
+		rp.Transport.(*http.Transport).CloseIdleConnections()
+	}
+}
+
+// bufferPool 复用响应拷贝缓冲区
+type bufferPool struct {
+	pool sync.Pool
+}
+
+var copyBuffers httputil.BufferPool = &bufferPool{
+	pool: sync.Pool{New: func() any { return make([]byte, copyBufferSize) }},
+}
+
+func (b *bufferPool) Get() []byte  { return b.pool.Get().([]byte) }
+func (b *bufferPool) Put(p []byte) { b.pool.Put(p) }
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/auth"
+	"github.com/hellobchain/gateway-server/pkg/breaker"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/proxy"
+)
+
+// breakerObservers 熔断器状态变化观察者，默认写日志
+var breakerObservers = breaker.Observers{breaker.LogObserver{}}
+
+// AddBreakerObserver 追加熔断器状态观察者，需在 Register 之前调用
+func AddBreakerObserver(o breaker.Observer) {
+	breakerObservers = append(breakerObservers, o)
+}
+
+// RegisterAdmin 注册管理接口
+func RegisterAdmin(r *gin.Engine, cfg config.AdminConfig) {
+	r.Use(gin.Recovery(), adminAuth(cfg.Token))
+	g := r.Group("/admin")
+	g.GET("/breakers", listBreakers)
+	g.POST("/breakers/force", forceBreaker)
+	g.GET("/splits", listSplits)
+	g.POST("/splits", setSplit)
+}
+
+// adminAuth 令牌为空时拒绝所有请求
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader("Authorization")
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+			auth.ResultCode(c, http.StatusUnauthorized, "invalid admin token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// listBreakers 各路由熔断器状态
+func listBreakers(c *gin.Context) {
+	list := make([]breaker.Status, 0)
+	for _, rt := range table.Load().sorted() {
+		list = append(list, rt.breakers.Status()...)
+	}
+	auth.ResultData(c, list)
+}
+
+type forceRequest struct {
+	Route    string `json:"route" binding:"required"` // 路由名称
+	Instance string `json:"instance"`                 // 后端实例，为空作用于整条路由
+	State    string `json:"state" binding:"required"` // open | closed | auto
+}
+
+// forceBreaker 人工强制熔断/放行，auto 恢复自动判断
+func forceBreaker(c *gin.Context) {
+	var req forceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		auth.ResultCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	st, err := breaker.ParseForceState(req.State)
+	if err != nil {
+		auth.ResultCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	rt := table.Load().find(req.Route)
+	if rt == nil {
+		auth.ResultCode(c, http.StatusNotFound, "route "+req.Route+" not found")
+		return
+	}
+	rt.breakers.Force(req.Instance, st)
+	logger.Warnf("[admin] force breaker %s %s -> %s", req.Route, req.Instance, req.State)
+	auth.ResultData(c, rt.breakers.Status())
+}
+
+// routeSplit 路由的目标组分流比例
+type routeSplit struct {
+	Route  string              `json:"route"`
+	Groups []proxy.GroupWeight `json:"groups"`
+}
+
+// listSplits 配置了目标组的路由及当前分流比例
+func listSplits(c *gin.Context) {
+	list := make([]routeSplit, 0)
+	for _, rt := range table.Load().sorted() {
+		if rt.split != nil {
+			list = append(list, routeSplit{Route: rt.cfg.GetName(), Groups: rt.split.Weights()})
+		}
+	}
+	auth.ResultData(c, list)
+}
+
+type splitRequest struct {
+	Route   string         `json:"route" binding:"required"`   // 路由名称
+	Weights map[string]int `json:"weights" binding:"required"` // 组名 -> 流量比例，未指定的组保持不变
+}
+
+// setSplit 调整分流比例，路由配置变化重建后恢复为配置文件中的比例
+func setSplit(c *gin.Context) {
+	var req splitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		auth.ResultCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	rt := table.Load().find(req.Route)
+	if rt == nil || rt.split == nil {
+		auth.ResultCode(c, http.StatusNotFound, "route "+req.Route+" has no target groups")
+		return
+	}
+	if err := rt.split.SetWeights(req.Weights); err != nil {
+		auth.ResultCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Warnf("[admin] set split %s -> %v", req.Route, req.Weights)
+	auth.ResultData(c, routeSplit{Route: rt.cfg.GetName(), Groups: rt.split.Weights()})
+}
+
+// sorted 按路径排序的路由列表
+func (t *routeTable) sorted() []*route {
+	list := make([]*route, 0, len(t.routes))
+	for _, rt := range t.routes {
+		list = append(list, rt)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].cfg.Path < list[j].cfg.Path })
+	return list
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+func TestGetLbInstancesSkipsBrokenTLS(t *testing.T) {
+	broken := &config.UpstreamTLSConfig{CAFile: "testdata/missing.pem"}
+	insts := getLbInstances(config.RoutesConfig{Path: "/dm", Targets: []config.RouterTargetsConfig{
+		{Target: "a:80", Protocol: "http"},
+		{Target: "b:443", Protocol: "https", TLS: broken},
+		{Target: "c:443", Protocol: "https", TLS: broken},
+		{Target: "d:443", Protocol: "https", TLS: &config.UpstreamTLSConfig{ServerName: "d.example.com"}},
+	}})
+	// CA 读取失败的目标不能以系统默认 TLS 配置继续转发
+	if len(insts) != 2 || insts[0].Addr != "a:80" || insts[1].Addr != "d:443" {
+		t.Fatalf("instances = %+v, want a:80 and d:443", insts)
+	}
+	if insts[1].TLS == nil || insts[1].TLS.ServerName != "d.example.com" {
+		t.Fatalf("tls = %+v, want server name d.example.com", insts[1].TLS)
+	}
+}
@@ -0,0 +1,407 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/auth"
+	"github.com/hellobchain/gateway-server/pkg/breaker"
+	"github.com/hellobchain/gateway-server/pkg/config"
+	"github.com/hellobchain/gateway-server/pkg/lb"
+	"github.com/hellobchain/gateway-server/pkg/routematch"
+	"github.com/hellobchain/gateway-server/proxy"
+)
+
+// route 单条路由运行时状态
+type route struct {
+	cfg       config.RoutesConfig // 路由配置
+	breaker   config.Breaker      // 合并后的熔断配置
+	split     *proxy.Splitter     // 目标组分流，未配置目标组时为空
+	checkers  []*lb.HealthChecker // 默认实例与各灰度规则实例的健康检查
+	balancers []lb.Balancer       // 与 checkers 对应的均衡器，下线时释放被动检查的定时器
+	proxies   *proxy.ProxyPool    // 上游连接池
+	breakers  *breaker.Group      // 熔断器
+	handler   gin.HandlerFunc     // 转发处理
+}
+
+// routeTable 路由表，构建后只读，热更新时整体替换
+type routeTable struct {
+	routes  map[string]*route           // routeKey -> route
+	matcher *routematch.Matcher[*route] // 按配置顺序添加的路由匹配器
+}
+
+var (
+	table    atomic.Pointer[routeTable] // 当前生效路由表
+	reloadMu sync.Mutex                 // 串行化路由表重建
+	closed   bool                       // 已关闭，不再重建路由表，由 reloadMu 保护
+	inflight atomic.Int64               // 转发中的请求数，含 websocket 等升级后的长连接
+)
+
+func newRoute(rule config.RoutesConfig, breakerCfg config.Breaker) *route {
+	var (
+		balancer  lb.Balancer
+		checker   *lb.HealthChecker
+		checkers  []*lb.HealthChecker
+		balancers []lb.Balancer
+		split     *proxy.Splitter
+	)
+	if len(rule.Groups) == 0 {
+		balancer, checker = newBalancer(rule, rule.Targets)
+		checkers, balancers = append(checkers, checker), append(balancers, balancer)
+	} else {
+		if len(rule.Targets) > 0 {
+			logger.Warnf("route %s: targets ignored when groups configured", rule.Path)
+		}
+		groups := make([]proxy.TargetGroup, len(rule.Groups))
+		percents := make([]int, len(rule.Groups))
+		for i, g := range rule.Groups {
+			groups[i].Name, percents[i] = g.Name, g.Percent
+			groups[i].Balancer, checker = newBalancer(rule, g.Targets)
+			checkers, balancers = append(checkers, checker), append(balancers, groups[i].Balancer)
+		}
+		split = proxy.NewSplitter(groups, percents, rule.Sticky)
+		balancer = groups[0].Balancer
+	}
+	canary := make([]proxy.CanaryRule, 0, len(rule.Match))
+	for i, m := range rule.Match {
+		cr, err := newCanaryRule(m)
+		if err != nil {
+			logger.Errorf("route %s match rule %d: %v, ignored", rule.Path, i, err)
+			continue
+		}
+		cr.Balancer, checker = newBalancer(rule, m.Targets)
+		checkers, balancers = append(checkers, checker), append(balancers, cr.Balancer)
+		canary = append(canary, cr)
+	}
+	rewrite, err := proxy.NewRewrite(rule.Rewrite, rule.Path)
+	if err != nil {
+		logger.Errorf("route %s rewrite: %v, ignored", rule.Path, err)
+	}
+	breakers := breaker.NewGroup(rule.GetName(), breakerSettings(breakerCfg), breakerCfg.PerInstance)
+	proxies := proxy.NewProxyPool(rule.Transport)
+	rt := &route{
+		cfg:       rule,
+		breaker:   breakerCfg,
+		split:     split,
+		checkers:  checkers,
+		balancers: balancers,
+		proxies:   proxies,
+		breakers:  breakers,
+		handler: proxy.LbHandler(proxy.Options{
+			Balancer: balancer,
+			Canary:   canary,
+			Split:    split,
+			Breakers: breakers,
+			Failure:  proxy.NewFailurePolicy(rule.Failure),
+			Retry:    proxy.NewRetryPolicy(rule.Retry),
+			Timeout:  proxy.NewTimeoutPolicy(timeoutConfig(rule)),
+			Proxies:  proxies,
+			Fallback: newFallback(rule),
+			HashKey:  proxy.NewHashKey(rule.HashKey),
+			Rewrite:  rewrite,
+
+			MaxBodyBytes: rule.MaxBodyBytes,
+		}),
+	}
+	return rt
+}
+
+// newBalancer 按路由的均衡策略、被动与主动健康检查为一组目标创建均衡器
+func newBalancer(rule config.RoutesConfig, targets []config.RouterTargetsConfig) (lb.Balancer, *lb.HealthChecker) {
+	rule.Targets = targets
+	insts := getLbInstances(rule)
+	balancer, err := lb.NewStrategy(rule.LbStrategy, insts)
+	if err != nil {
+		logger.Errorf("route %s: %v, fallback to %s", rule.Path, err, lb.StrategyRoundRobin)
+		balancer = lb.New(insts)
+	}
+	if o := rule.Outlier; o.Enabled {
+		balancer = lb.NewOutlierDetector(balancer, lb.OutlierOptions{
+			ConsecutiveErrors:  o.ConsecutiveErrors,
+			BaseEjectionTime:   o.BaseEjectionTime,
+			MaxEjectionTime:    o.MaxEjectionTime,
+			MaxEjectionPercent: o.MaxEjectionPercent,
+		})
+	}
+	checker := lb.NewHealthChecker(balancer, checkOptions(rule))
+	checker.Start(context.Background(), insts)
+	return balancer, checker
+}
+
+func newCanaryRule(m config.MatchRuleConfig) (proxy.CanaryRule, error) {
+	cr := proxy.CanaryRule{Name: m.Name}
+	if len(m.Targets) == 0 {
+		return cr, errors.New("no targets")
+	}
+	// 没有条件的规则会把整条路由的流量都转到灰度目标
+	if len(m.Predicates) == 0 {
+		return cr, errors.New("no predicates")
+	}
+	for _, pc := range m.Predicates {
+		p, err := proxy.NewPredicate(pc)
+		if err != nil {
+			return cr, err
+		}
+		cr.Predicates = append(cr.Predicates, p)
+	}
+	return cr, nil
+}
+
+// stop 停止探活与被动检查并关闭上游空闲连接
+func (rt *route) stop() {
+	for _, c := range rt.checkers {
+		c.Stop()
+	}
+	for _, b := range rt.balancers {
+		if s, ok := b.(lb.Stopper); ok {
+			s.Stop()
+		}
+	}
+	rt.proxies.Close()
+}
+
+// routeKey 路由标识，Host、方法与路径都相同视为同一路由
+func routeKey(rule config.RoutesConfig) string {
+	return strings.Join(rule.Hosts, ",") + " " + strings.Join(rule.Methods, ",") + " " + rule.Path
+}
+
+// timeoutConfig 兼容 retry.per_try_timeout
+func timeoutConfig(rule config.RoutesConfig) config.TimeoutConfig {
+	cfg := rule.Timeout
+	if cfg.PerTry == 0 {
+		cfg.PerTry = rule.Retry.PerTryTimeout
+	}
+	return cfg
+}
+
+func newFallback(rule config.RoutesConfig) proxy.Fallback {
+	switch rule.Fallback.Type {
+	case proxy.FallbackRoute:
+		return routeFallback{from: rule.GetName(), to: rule.Fallback.Route, jwt: rule.IsJwt}
+	case proxy.FallbackCache:
+		// 缓存按 URI 共享，需要鉴权的路由会把一个用户的响应返回给其他用户
+		if rule.IsJwt {
+			logger.Errorf("route %s: cache fallback is not allowed on jwt routes, ignored", rule.Path)
+			return nil
+		}
+	}
+	return proxy.NewFallback(rule.Fallback)
+}
+
+func checkOptions(rule config.RoutesConfig) lb.CheckOptions {
+	hc := rule.HealthCheck
+	interval := hc.Interval
+	if interval == 0 { // 兼容 health_check_interval（秒），都为空时默认 5 秒
+		interval = time.Duration(rule.HealthCheckInterval) * time.Second
+	}
+	return lb.CheckOptions{
+		Interval:           interval,
+		Timeout:            hc.Timeout,
+		Jitter:             hc.Jitter,
+		HealthyThreshold:   hc.HealthyThreshold,
+		UnhealthyThreshold: hc.UnhealthyThreshold,
+		Prober:             newProber(rule),
+	}
+}
+
+// newProber 按配置创建探活方式，配置有误时退回 TCP 探测
+func newProber(rule config.RoutesConfig) lb.Prober {
+	hc := rule.HealthCheck
+	if hc.Type != "http" {
+		return lb.TCPProber{}
+	}
+	check := lb.HTTPCheck{
+		Method:       hc.Method,
+		Path:         hc.Path,
+		Headers:      hc.Headers,
+		BodyContains: hc.BodyContains,
+	}
+	var err error
+	if check.StatusMin, check.StatusMax, err = lb.ParseStatusRange(hc.ExpectedStatus); err != nil {
+		logger.Errorf("route %s health check: %v, fallback to tcp", rule.Path, err)
+		return lb.TCPProber{}
+	}
+	if hc.BodyRegex != "" {
+		if check.BodyRegex, err = regexp.Compile(hc.BodyRegex); err != nil {
+			logger.Errorf("route %s health check: %v, fallback to tcp", rule.Path, err)
+			return lb.TCPProber{}
+		}
+	}
+	return lb.NewHTTPProber(check)
+}
+
+func breakerSettings(cfg config.Breaker) breaker.Settings {
+	return breaker.Settings{
+		Enabled:               cfg.Enabled,
+		MaxRequests:           cfg.MaxRequests,
+		Interval:              cfg.Interval,
+		Buckets:               cfg.Buckets,
+		Timeout:               cfg.Timeout,
+		ErrorPercentThreshold: cfg.ErrorPercent,
+		MinRequestAmount:      cfg.MinRequestAmount,
+		SlowCallThreshold:     cfg.SlowCallThreshold,
+		SlowCallPercent:       cfg.SlowCallPercent,
+		Mode:                  cfg.Mode,
+		K:                     cfg.K,
+		Observer:              breakerObservers,
+	}
+}
+
+// loadRoutes 根据配置构建新路由表并原子替换，未变化的路由复用原有均衡器与探活
+func loadRoutes(cfg config.Cfg) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if closed {
+		return
+	}
+	old := table.Load()
+	next := &routeTable{routes: make(map[string]*route, len(cfg.Routes)), matcher: routematch.New[*route]()}
+	names := make(map[string]bool, len(cfg.Routes))
+	for _, rule := range cfg.Routes {
+		key := routeKey(rule)
+		if _, ok := next.routes[key]; ok {
+			continue // 已存在
+		}
+		// 管理接口与降级按名称查找路由，重名时无法确定命中哪一条
+		name := rule.GetName()
+		if names[name] {
+			logger.Errorf("route %s: duplicate name %s, ignored", key, name)
+			continue
+		}
+		names[name] = true
+		// 路由级熔断配置覆盖全局配置
+		breakerCfg := cfg.Breaker.Merge(rule.Breaker)
+		if old != nil {
+			if rt, ok := old.routes[key]; ok && reflect.DeepEqual(rt.cfg, rule) && rt.breaker == breakerCfg {
+				next.routes[key] = rt
+				next.matcher.Add(rule, rt)
+				continue
+			}
+		}
+		rt := newRoute(rule, breakerCfg)
+		next.routes[key] = rt
+		next.matcher.Add(rule, rt)
+		logger.Infof("registered route: %s -> %s", key, toString(rule.Targets))
+	}
+	// 配置读取异常时可能得到空路由列表，保留当前路由表，避免全部请求 404
+	if len(next.routes) == 0 && old != nil && len(old.routes) > 0 {
+		logger.Warnf("reload yields no routes, keep current %d routes", len(old.routes))
+		return
+	}
+	table.Store(next)
+	if old == nil {
+		return
+	}
+	// 旧路由不再被新请求命中，停止探活并关闭空闲连接；进行中的请求仍持有旧的均衡器完成转发
+	for key, rt := range old.routes {
+		if next.routes[key] != rt {
+			rt.stop()
+			logger.Infof("unregistered route: %s", key)
+		}
+	}
+}
+
+// Drain 等待转发中的请求结束。http.Server.Shutdown 不跟踪被劫持的升级连接，需单独等待
+func Drain(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Close 停止所有路由的探活并关闭上游空闲连接，之后配置变化不再重建路由表
+func Close() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	closed = true
+	if t := table.Load(); t != nil {
+		for _, rt := range t.routes {
+			rt.stop()
+		}
+	}
+}
+
+// find 按名称查找路由
+func (t *routeTable) find(name string) *route {
+	for _, rt := range t.routes {
+		if rt.cfg.GetName() == name {
+			return rt
+		}
+	}
+	return nil
+}
+
+// dispatch 在当前路由表中查找路由并转发
+func dispatch(c *gin.Context) {
+	res, ok := table.Load().matcher.Match(routematch.Host(c.Request), c.Request.Method, c.Request.URL.Path)
+	if !ok {
+		if len(res.Allow) > 0 {
+			c.Header("Allow", strings.ToUpper(strings.Join(res.Allow, ", ")))
+			auth.ResultStatus(c, http.StatusMethodNotAllowed, c.Request.Method+" not allowed")
+			return
+		}
+		auth.ResultCode(c, http.StatusNotFound, c.Request.URL.Path+" not found")
+		return
+	}
+	inflight.Add(1)
+	defer inflight.Add(-1)
+	serve(c, res.Value, res.Rest)
+}
+
+func serve(c *gin.Context, rt *route, rest string) {
+	for i := range c.Params {
+		if c.Params[i].Key == "proxyPath" {
+			c.Params[i].Value = rest
+			rt.handler(c)
+			return
+		}
+	}
+	c.Params = append(c.Params, gin.Param{Key: "proxyPath", Value: rest})
+	rt.handler(c)
+}
+
+const fallbackKey = "gateway:fallback"
+
+// routeFallback 降级时转发到备用路由，路径按备用路由前缀重写
+type routeFallback struct {
+	from string // 当前路由名称
+	to   string // 备用路由名称
+	jwt  bool   // 当前路由是否已经过 JWT 鉴权
+}
+
+func (f routeFallback) Serve(c *gin.Context) bool {
+	// 备用路由不再级联降级
+	if _, ok := c.Get(fallbackKey); ok {
+		return false
+	}
+	rt := table.Load().find(f.to)
+	if rt == nil || rt.cfg.GetName() == f.from {
+		logger.Warnf("fallback route %s not found", f.to)
+		return false
+	}
+	// 鉴权中间件只按原始路径判断，不能借降级绕过备用路由的 JWT 鉴权
+	if rt.cfg.IsJwt && !f.jwt {
+		logger.Errorf("fallback route %s requires jwt but route %s does not, refused", f.to, f.from)
+		return false
+	}
+	c.Set(fallbackKey, true)
+	rest := c.Param("proxyPath")
+	c.Request.URL.Path = strings.TrimSuffix(rt.cfg.Path, "/") + rest
+	c.Header(proxy.FallbackHeader, proxy.FallbackRoute)
+	serve(c, rt, rest)
+	return true
+}
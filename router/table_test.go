@@ -0,0 +1,188 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hellobchain/gateway-server/pkg/config"
+)
+
+// newUpstream 返回固定内容的上游地址
+func newUpstream(t *testing.T, body string) config.RouterTargetsConfig {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return config.RouterTargetsConfig{Target: strings.TrimPrefix(srv.URL, "http://"), Protocol: "http", Weight: 1}
+}
+
+// newTestGateway 加载路由并返回网关地址，结束时停止全部路由
+func newTestGateway(t *testing.T, routes ...config.RoutesConfig) string {
+	loadRoutes(config.Cfg{Routes: routes})
+	t.Cleanup(func() {
+		if old := table.Swap(nil); old != nil {
+			for _, rt := range old.routes {
+				rt.stop()
+			}
+		}
+	})
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.NoRoute(dispatch)
+	gw := httptest.NewServer(r)
+	t.Cleanup(gw.Close)
+	return gw.URL
+}
+
+func send(t *testing.T, method, host, url string) (*http.Response, string) {
+	req, _ := http.NewRequest(method, url, nil)
+	req.Host = host
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func TestRouteFallback(t *testing.T) {
+	backup := newUpstream(t, "backup")
+	gw := newTestGateway(t,
+		// 没有目标实例，每次请求都降级
+		config.RoutesConfig{Path: "/open", Fallback: config.FallbackConfig{Type: "route", Route: "backup"}},
+		config.RoutesConfig{Path: "/leak", Fallback: config.FallbackConfig{Type: "route", Route: "private"}},
+		config.RoutesConfig{Name: "backup", Path: "/backup", Targets: []config.RouterTargetsConfig{backup}},
+		config.RoutesConfig{Name: "private", Path: "/private", IsJwt: true, Targets: []config.RouterTargetsConfig{newUpstream(t, "secret")}},
+	)
+	if resp, body := send(t, http.MethodGet, "", gw+"/open/x"); resp.StatusCode != http.StatusOK || body != "backup" {
+		t.Fatalf("fallback = %d %q, want backup response", resp.StatusCode, body)
+	}
+	// 未鉴权的路由不能降级到需要 JWT 的路由
+	if _, body := send(t, http.MethodGet, "", gw+"/leak/x"); !strings.Contains(body, "no available instance") {
+		t.Fatalf("fallback = %q, want jwt route refused", body)
+	}
+}
+
+func TestDispatchHostAndMethod(t *testing.T) {
+	target := func(body string) []config.RouterTargetsConfig {
+		return []config.RouterTargetsConfig{newUpstream(t, body)}
+	}
+	gw := newTestGateway(t,
+		config.RoutesConfig{Path: "/svc", Hosts: []string{"a.example.com"}, Targets: target("exact")},
+		config.RoutesConfig{Path: "/svc", Hosts: []string{"*.example.com"}, Targets: target("wildcard")},
+		config.RoutesConfig{Path: "/svc", Methods: []string{"GET"}, Targets: target("get")},
+		config.RoutesConfig{Path: "/svc", Methods: []string{"POST"}, Targets: target("post")},
+	)
+	cases := []struct {
+		method, host, want string
+	}{
+		{http.MethodGet, "a.example.com", "exact"},
+		{http.MethodGet, "b.example.com", "wildcard"},
+		{http.MethodGet, "other.com", "get"},
+		{http.MethodPost, "other.com:8080", "post"},
+	}
+	for _, tc := range cases {
+		if resp, body := send(t, tc.method, tc.host, gw+"/svc/x"); resp.StatusCode != http.StatusOK || body != tc.want {
+			t.Fatalf("%s %s = %d %q, want %q", tc.method, tc.host, resp.StatusCode, body, tc.want)
+		}
+	}
+	resp, _ := send(t, http.MethodDelete, "other.com", gw+"/svc/x")
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "GET, POST" {
+		t.Fatalf("DELETE = %d allow %q, want 405 allow GET, POST", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+func TestRouteNamesUnique(t *testing.T) {
+	newTestGateway(t,
+		config.RoutesConfig{Path: "/svc", Methods: []string{"GET"}},
+		config.RoutesConfig{Path: "/svc", Methods: []string{"POST"}},
+		config.RoutesConfig{Path: "/svc", Hosts: []string{"a.example.com"}},
+		config.RoutesConfig{Name: "dup", Path: "/a"},
+		config.RoutesConfig{Name: "dup", Path: "/b"},
+	)
+	// 同一 path 按方法与 Host 区分的路由默认名称不同，显式重名的路由被忽略
+	tbl := table.Load()
+	for name, path := range map[string]string{"GET /svc": "/svc", "POST /svc": "/svc", "a.example.com/svc": "/svc", "dup": "/a"} {
+		if rt := tbl.find(name); rt == nil || rt.cfg.Path != path {
+			t.Fatalf("find(%q) = %v, want route %s", name, rt, path)
+		}
+	}
+	if n := len(tbl.routes); n != 4 {
+		t.Fatalf("loaded %d routes, want 4", n)
+	}
+}
+
+// probedUpstream 统计健康检查探测次数的上游
+func probedUpstream(t *testing.T, probes *atomic.Int32) config.RouterTargetsConfig {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			probes.Add(1)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return config.RouterTargetsConfig{Target: strings.TrimPrefix(srv.URL, "http://"), Protocol: "http", Weight: 1}
+}
+
+// probing 探测次数在一段时间内仍在增长时认为健康检查未停止，
+// 先等待停止前已发出的探测请求到达上游
+func probing(probes *atomic.Int32) bool {
+	time.Sleep(20 * time.Millisecond)
+	n := probes.Load()
+	time.Sleep(100 * time.Millisecond)
+	return probes.Load() != n
+}
+
+func TestLoadRoutesReload(t *testing.T) {
+	var keepProbes, dropProbes atomic.Int32
+	check := config.HealthCheckConfig{Type: "http", Interval: 10 * time.Millisecond, Path: "/health"}
+	keep := config.RoutesConfig{Path: "/keep", HealthCheck: check, Targets: []config.RouterTargetsConfig{probedUpstream(t, &keepProbes)}}
+	drop := config.RoutesConfig{Path: "/drop", HealthCheck: check, Targets: []config.RouterTargetsConfig{probedUpstream(t, &dropProbes)}}
+	gw := newTestGateway(t, keep, drop)
+	first := table.Load()
+	if !probing(&keepProbes) || !probing(&dropProbes) {
+		t.Fatal("health checks not running")
+	}
+
+	// 未变化的路由原样复用，被删除的路由停止探活，新表整体替换
+	loadRoutes(config.Cfg{Routes: []config.RoutesConfig{keep}})
+	second := table.Load()
+	if second == first || second.routes[routeKey(keep)] != first.routes[routeKey(keep)] {
+		t.Fatal("unchanged route not reused in the new table")
+	}
+	if _, body := send(t, http.MethodGet, "", gw+"/drop"); !strings.Contains(body, "not found") {
+		t.Fatalf("removed route = %q, want not found", body)
+	}
+	if probing(&dropProbes) || !probing(&keepProbes) {
+		t.Fatal("removed route still probing or kept route stopped")
+	}
+
+	// 全局熔断配置变化时路由重建
+	breakerCfg := config.Breaker{Enabled: true}
+	loadRoutes(config.Cfg{Routes: []config.RoutesConfig{keep}, Breaker: breakerCfg})
+	if rt := table.Load().routes[routeKey(keep)]; rt == second.routes[routeKey(keep)] || rt.breaker != breakerCfg {
+		t.Fatal("route not rebuilt after breaker config changed")
+	}
+
+	// 目标变化时路由重建，被替换的旧路由停止探活
+	var movedProbes atomic.Int32
+	moved := keep
+	moved.Targets = []config.RouterTargetsConfig{probedUpstream(t, &movedProbes)}
+	loadRoutes(config.Cfg{Routes: []config.RoutesConfig{moved}, Breaker: breakerCfg})
+	if probing(&keepProbes) || !probing(&movedProbes) {
+		t.Fatal("replaced route still probing or new route not probing")
+	}
+
+	// 重载得到空路由列表时保留当前路由表
+	current := table.Load()
+	loadRoutes(config.Cfg{})
+	if table.Load() != current {
+		t.Fatal("empty reload replaced the route table")
+	}
+}